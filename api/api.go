@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -19,6 +20,7 @@ import (
 	"github.com/twitchscience/blueprint/bpdb"
 	"github.com/twitchscience/blueprint/core"
 	"github.com/twitchscience/blueprint/ingester"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
 	"github.com/zenazn/goji"
 	"github.com/zenazn/goji/graceful"
 	"github.com/zenazn/goji/web"
@@ -39,7 +41,12 @@ type server struct {
 	slackbotURL            string
 	goCache                *cache.Cache
 	cacheTimeout           time.Duration
+	blacklistMutex         *sync.RWMutex
 	blacklistRe            []*regexp.Regexp
+	blacklistLoaded        time.Time
+	linter                 *bpdb.Linter
+	eventNamePolicy        *bpdb.EventNamePolicy
+	metadataValidators     map[scoop_protocol.EventMetadataType]metadataValidator
 	readonly               bool
 	s3Uploader             s3manageriface.UploaderAPI
 	s3BpConfigsBucketName  string
@@ -89,12 +96,16 @@ func New(
 		ingesterController:     ingCont,
 		slackbotURL:            slackbotURL,
 		goCache:                cache.New(5*time.Minute, 10*time.Minute),
+		blacklistMutex:         &sync.RWMutex{},
 		readonly:               readonly,
 		s3Uploader:             s3Uploader,
 	}
 	if err := s.loadConfig(conf); err != nil {
 		logger.WithError(err).Fatal("failed to load config")
 	}
+	if err := s.loadBlacklist(conf.Blacklist); err != nil {
+		logger.WithError(err).Fatal("failed to load blacklist")
+	}
 	return s
 }
 
@@ -122,7 +133,9 @@ func (s *MockS3UploaderAPI) Upload(input *s3manager.UploadInput, f ...func(*s3ma
 	k := *input.Key
 	if !strings.HasSuffix(k, schemaConfigS3Key) &&
 		!strings.HasSuffix(k, kinesisConfigS3Key) &&
-		!strings.HasSuffix(k, eventMetadataConfigS3Key) {
+		!strings.HasSuffix(k, eventMetadataConfigS3Key) &&
+		!strings.HasSuffix(k, avroSchemasS3Key) &&
+		!strings.HasSuffix(k, parquetSchemasS3Key) {
 		return nil, fmt.Errorf("Invalid S3 config key %s", k)
 	}
 	s.uploadSuccessful = true
@@ -154,19 +167,43 @@ func (s *server) setupReadonlyAPI() {
 
 	roAPI.Get("/schemas", s.allSchemas)
 	roAPI.Get("/schema/:id", s.schema)
+	roAPI.Get("/schema/:id/diff", s.schemaDiff)
+	roAPI.Get("/schema/:id/history", s.schemaHistory)
+	roAPI.Get("/schema/:id/ddl", s.schemaDDL)
+	roAPI.Get("/schema/:id/jsonschema", s.schemaJSONSchema)
+	roAPI.Get("/schema/:id/avro", s.schemaAvro)
+	roAPI.Get("/schema/:id/parquet", s.schemaParquet)
+	roAPI.Get("/schema/:id/gostruct", s.schemaGoStruct)
+	roAPI.Get("/gostructs", s.allGoStructs)
+	roAPI.Post("/schema/:id/validate", s.validateEvents)
+	roAPI.Get("/lint/:id", s.lintSchema)
+	roAPI.Get("/eventname/:name/check", s.checkEventName)
+	roAPI.Get("/blacklist/test/:name", s.testBlacklist)
+	roAPI.Get("/jsonschemas", s.allJSONSchemas)
+	roAPI.Get("/pii", s.sensitiveColumns)
 	roAPI.Get("/droppable/schema/:id", s.droppableSchema)
 	roAPI.Get("/maintenance", s.getMaintenanceMode)
 	roAPI.Get("/maintenance/:schema", s.getMaintenanceMode)
 	roAPI.Get("/migration/:schema", s.migration)
+	roAPI.Get("/migration/:schema/ddl", s.migrationDDL)
 	roAPI.Get("/types", s.types)
 	roAPI.Get("/suggestions", s.listSuggestions)
 	roAPI.Get("/suggestion/:id", s.suggestion)
 	roAPI.Get("/stats", s.stats)
 	roAPI.Get("/allmetadata", s.allEventMetadata)
 	roAPI.Get("/metadata/:event", s.eventMetadata)
+	roAPI.Get("/metadata/:event/history", s.eventMetadataHistory)
+	roAPI.Get("/datastores", s.datastores)
 
 	goji.Get("/schemas", roAPI)
 	goji.Get("/schema/*", roAPI)
+	goji.Post("/schema/:id/validate", roAPI)
+	goji.Get("/jsonschemas", roAPI)
+	goji.Get("/pii", roAPI)
+	goji.Get("/gostructs", roAPI)
+	goji.Get("/lint/*", roAPI)
+	goji.Get("/eventname/*", roAPI)
+	goji.Get("/blacklist/test/*", roAPI)
 	goji.Get("/droppable/schema/*", roAPI)
 	goji.Get("/maintenance", roAPI)
 	goji.Get("/maintenance/*", roAPI)
@@ -177,6 +214,7 @@ func (s *server) setupReadonlyAPI() {
 	goji.Get("/stats", roAPI)
 	goji.Get("/allmetadata", roAPI)
 	goji.Get("/metadata/*", roAPI)
+	goji.Get("/datastores", roAPI)
 
 	roAPI.Get("/kinesisconfigs", s.allKinesisConfigs)
 	roAPI.Get("/kinesisconfig/:account/:type/:name", s.kinesisconfig)
@@ -195,13 +233,19 @@ func (s *server) authWriteAPI() *web.Mux {
 	authWriteAPI.Post("/force_load", s.forceLoad)
 	authWriteAPI.Put("/schema", s.createSchema)
 	authWriteAPI.Post("/schema/:id", s.updateSchema)
+	authWriteAPI.Post("/schema/:id/revert", s.revertSchema)
+	authWriteAPI.Post("/schema/:id/clone", s.cloneSchema)
+	authWriteAPI.Post("/schema/:id/column/:column/doc", s.updateColumnDoc)
+	authWriteAPI.Post("/schemas/bulk", s.bulkUpdateSchemas)
 	authWriteAPI.Post("/drop/schema", s.dropSchema)
 	authWriteAPI.Post("/removesuggestion/:id", s.removeSuggestion)
 	authWriteAPI.Post("/metadata/:event", s.updateEventMetadata)
+	authWriteAPI.Post("/metadata/:event/revert", s.revertEventMetadata)
 
 	goji.Post("/force_load", authWriteAPI)
 	goji.Put("/schema", authWriteAPI)
 	goji.Post("/schema/*", authWriteAPI)
+	goji.Post("/schemas/bulk", authWriteAPI)
 	goji.Post("/drop/schema", authWriteAPI)
 	goji.Post("/removesuggestion/*", authWriteAPI)
 	goji.Post("/metadata/*", authWriteAPI)
@@ -210,7 +254,8 @@ func (s *server) authWriteAPI() *web.Mux {
 }
 
 // Create the write API available only to admins. Currently limited to toggling maintenance
-// modes and modifying Kinesis configs.
+// modes, restoring dropped schemas, deleting columns before their sunset, checking schema
+// snapshots, managing the event name blacklist and modifying Kinesis configs.
 func (s *server) authAdminAPI() *web.Mux {
 	adminAPI := web.New()
 	adminAPI.Use(context.ClearHandler)
@@ -220,6 +265,27 @@ func (s *server) authAdminAPI() *web.Mux {
 	goji.Post("/maintenance", adminAPI)
 	goji.Post("/maintenance/*", adminAPI)
 
+	adminAPI.Post("/restore/schema", s.restoreSchema)
+	goji.Post("/restore/schema", adminAPI)
+
+	adminAPI.Post("/force/schema/:id", s.forceUpdateSchema)
+	adminAPI.Post("/force/schema/:id/revert", s.forceRevertSchema)
+	goji.Post("/force/schema/*", adminAPI)
+
+	adminAPI.Get("/snapshots/drift", s.snapshotDrift)
+	adminAPI.Post("/snapshots/rebuild", s.rebuildSnapshots)
+	goji.Get("/snapshots/drift", adminAPI)
+	goji.Post("/snapshots/rebuild", adminAPI)
+
+	adminAPI.Get("/blacklist", s.blacklistPatterns)
+	adminAPI.Get("/blacklist/history", s.blacklistHistory)
+	adminAPI.Put("/blacklist", s.addBlacklistPattern)
+	adminAPI.Delete("/blacklist", s.removeBlacklistPattern)
+	goji.Get("/blacklist", adminAPI)
+	goji.Get("/blacklist/history", adminAPI)
+	goji.Put("/blacklist", adminAPI)
+	goji.Delete("/blacklist", adminAPI)
+
 	adminAPI.Put("/kinesisconfig", s.createKinesisConfig)
 	adminAPI.Post("/kinesisconfig/:account/:type/:name", s.updateKinesisConfig)
 	adminAPI.Post("/drop/kinesisconfig", s.dropKinesisConfig)
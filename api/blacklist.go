@@ -0,0 +1,205 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	// blacklistRefreshInterval is how often the blacklist is reread from the
+	// db, so that changes made through other instances are picked up.
+	blacklistRefreshInterval = time.Minute
+
+	// blacklistImportUser is the user recorded for patterns imported from the config.
+	blacklistImportUser = "config"
+)
+
+// blacklistChangeRequest is the body of a request adding or removing a pattern.
+type blacklistChangeRequest struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+// blacklistTest is the result of testing a name against the blacklist.
+type blacklistTest struct {
+	EventName   string
+	Blacklisted bool
+	Pattern     string
+}
+
+// compileBlacklist compiles the patterns, which match case insensitively.
+func compileBlacklist(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(strings.ToLower(pattern))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// loadBlacklist reads the blacklist from the db. If it has never been changed,
+// the patterns in the config are imported into it first; readonly instances
+// keep using the config instead.
+func (s *server) loadBlacklist(configured []string) error {
+	if s.bpdbBackend == nil {
+		return nil
+	}
+	history, err := s.bpdbBackend.BlacklistHistory()
+	if err != nil {
+		return fmt.Errorf("reading blacklist history: %v", err)
+	}
+	if len(history) == 0 {
+		if s.readonly {
+			return nil
+		}
+		err = s.bpdbBackend.ImportBlacklistPatterns(configured, blacklistImportUser, "imported from config")
+		if err != nil {
+			return fmt.Errorf("importing blacklist: %v", err)
+		}
+	}
+	return s.reloadBlacklist()
+}
+
+// reloadBlacklist recompiles the blacklist from the patterns in the db.
+func (s *server) reloadBlacklist() error {
+	current, err := s.bpdbBackend.BlacklistPatterns()
+	if err != nil {
+		return fmt.Errorf("reading blacklist: %v", err)
+	}
+	patterns := make([]string, 0, len(current))
+	for _, change := range current {
+		patterns = append(patterns, change.Pattern)
+	}
+	res, err := compileBlacklist(patterns)
+	if err != nil {
+		return fmt.Errorf("compiling blacklist: %v", err)
+	}
+	s.blacklistMutex.Lock()
+	defer s.blacklistMutex.Unlock()
+	s.blacklistRe = res
+	s.blacklistLoaded = time.Now()
+	return nil
+}
+
+// blacklistMatch returns the pattern in the blacklist matching name, or the
+// empty string if it is not blacklisted. The blacklist is reread first if it
+// is stale, keeping the old one if that fails.
+func (s *server) blacklistMatch(name string) string {
+	s.blacklistMutex.RLock()
+	stale := !s.blacklistLoaded.IsZero() && time.Since(s.blacklistLoaded) > blacklistRefreshInterval
+	s.blacklistMutex.RUnlock()
+	if stale {
+		if err := s.reloadBlacklist(); err != nil {
+			logger.WithError(err).Error("Failed to refresh blacklist")
+		}
+	}
+
+	name = strings.ToLower(name)
+	s.blacklistMutex.RLock()
+	defer s.blacklistMutex.RUnlock()
+	for _, re := range s.blacklistRe {
+		if re.MatchString(name) {
+			return re.String()
+		}
+	}
+	return ""
+}
+
+// isBlacklisted check whether name matches any regex in the blacklist (case insensitive).
+// It returns false when name is not blacklisted or an error occurs.
+func (s *server) isBlacklisted(name string) bool {
+	return s.blacklistMatch(name) != ""
+}
+
+func (s *server) blacklistPatterns(c web.C, w http.ResponseWriter, r *http.Request) {
+	patterns, err := s.bpdbBackend.BlacklistPatterns()
+	if err != nil {
+		core.NewServerWebError(err).ReportError(w, "Error getting blacklist")
+		return
+	}
+	writeStructToResponse(w, patterns)
+}
+
+func (s *server) blacklistHistory(c web.C, w http.ResponseWriter, r *http.Request) {
+	history, err := s.bpdbBackend.BlacklistHistory()
+	if err != nil {
+		core.NewServerWebError(err).ReportError(w, "Error getting blacklist history")
+		return
+	}
+	writeStructToResponse(w, history)
+}
+
+// setBlacklistPatternHelper adds the pattern in the request to the blacklist
+// or removes it, and recompiles the blacklist.
+func (s *server) setBlacklistPatternHelper(body io.ReadCloser, blacklisted bool, user string) *core.WebError {
+	var req blacklistChangeRequest
+	if err := decodeBody(body, &req); err != nil {
+		return core.NewUserWebError(err)
+	}
+	if req.Pattern == "" {
+		return core.NewUserWebErrorf("pattern is required")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return core.NewUserWebErrorf("reason is required")
+	}
+	if _, err := regexp.Compile(strings.ToLower(req.Pattern)); err != nil {
+		return core.NewUserWebErrorf("invalid pattern %s: %v", req.Pattern, err)
+	}
+	current, err := s.bpdbBackend.BlacklistPatterns()
+	if err != nil {
+		return core.NewServerWebError(err)
+	}
+	present := false
+	for _, change := range current {
+		present = present || change.Pattern == req.Pattern
+	}
+	if blacklisted && present {
+		return core.NewUserWebErrorf("%s is already blacklisted", req.Pattern)
+	}
+	if !blacklisted && !present {
+		return core.NewUserWebErrorf("%s is not blacklisted", req.Pattern)
+	}
+	if err = s.bpdbBackend.SetBlacklistPattern(req.Pattern, blacklisted, user, req.Reason); err != nil {
+		return core.NewServerWebError(err)
+	}
+	logger.WithField("pattern", req.Pattern).
+		WithField("blacklisted", blacklisted).
+		WithField("reason", req.Reason).
+		Info("Blacklist changed")
+	return core.NewServerWebError(s.reloadBlacklist())
+}
+
+func (s *server) addBlacklistPattern(c web.C, w http.ResponseWriter, r *http.Request) {
+	webErr := s.setBlacklistPatternHelper(r.Body, true, c.Env["username"].(string))
+	if webErr != nil {
+		webErr.ReportError(w, "Error adding blacklist pattern")
+	}
+}
+
+func (s *server) removeBlacklistPattern(c web.C, w http.ResponseWriter, r *http.Request) {
+	webErr := s.setBlacklistPatternHelper(r.Body, false, c.Env["username"].(string))
+	if webErr != nil {
+		webErr.ReportError(w, "Error removing blacklist pattern")
+	}
+}
+
+func (s *server) testBlacklist(c web.C, w http.ResponseWriter, r *http.Request) {
+	name := c.URLParams["name"]
+	pattern := s.blacklistMatch(name)
+	writeStructToResponse(w, blacklistTest{
+		EventName:   name,
+		Blacklisted: pattern != "",
+		Pattern:     pattern,
+	})
+}
@@ -1,6 +1,18 @@
 package api
 
-import "testing"
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/test"
+)
 
 func TestBlacklist(t *testing.T) {
 	conf := Config{
@@ -35,3 +47,69 @@ func TestBlacklist(t *testing.T) {
 		}
 	}
 }
+
+func changeBlacklist(s *server, method, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c := web.C{Env: map[interface{}]interface{}{"username": "admin"}}
+	req, _ := http.NewRequest(method, "/blacklist", strings.NewReader(body))
+	if method == "PUT" {
+		s.addBlacklistPattern(c, recorder, req)
+	} else {
+		s.removeBlacklistPattern(c, recorder, req)
+	}
+	return recorder
+}
+
+func testBlacklistName(t *testing.T, s *server, name string) blacklistTest {
+	recorder := httptest.NewRecorder()
+	c := web.C{URLParams: map[string]string{"name": name}}
+	req, _ := http.NewRequest("GET", "/blacklist/test/"+name, nil)
+	s.testBlacklist(c, recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var result blacklistTest
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	return result
+}
+
+func TestBlacklistChanges(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	conf := Config{Blacklist: []string{"^dfp_.*$"}}
+	s := New("", bpdbBackend, nil, nil, &conf, nil, "", false, NewMockS3Uploader()).(*server)
+
+	history, err := bpdbBackend.BlacklistHistory()
+	require.Nil(t, err)
+	require.Len(t, history, 1, "config patterns are imported")
+	require.Equal(t, blacklistImportUser, history[0].User)
+	require.Equal(t, blacklistTest{EventName: "DFP_ads", Blacklisted: true, Pattern: "^dfp_.*$"},
+		testBlacklistName(t, s, "DFP_ads"))
+
+	recorder := changeBlacklist(s, "PUT", `{"pattern": "_test$", "reason": "no test events"}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.True(t, testBlacklistName(t, s, "video_play_test").Blacklisted)
+
+	assertRequestBad(t, "duplicate pattern", changeBlacklist(s, "PUT", `{"pattern": "_test$", "reason": "again"}`),
+		"Error adding blacklist pattern: _test$ is already blacklisted")
+	assertRequestBad(t, "invalid pattern", changeBlacklist(s, "PUT", `{"pattern": "(", "reason": "oops"}`), "")
+	assertRequestBad(t, "missing reason", changeBlacklist(s, "DELETE", `{"pattern": "^dfp_.*$"}`),
+		"Error removing blacklist pattern: reason is required")
+	assertRequestBad(t, "absent pattern", changeBlacklist(s, "DELETE", `{"pattern": "^wow$", "reason": "gone"}`),
+		"Error removing blacklist pattern: ^wow$ is not blacklisted")
+
+	recorder = changeBlacklist(s, "DELETE", `{"pattern": "^dfp_.*$", "reason": "dfp is back"}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	require.Equal(t, blacklistTest{EventName: "dfp_ads"}, testBlacklistName(t, s, "dfp_ads"))
+
+	history, err = bpdbBackend.BlacklistHistory()
+	require.Nil(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, "^dfp_.*$", history[0].Pattern)
+	require.False(t, history[0].Blacklisted)
+	require.Equal(t, "admin", history[0].User)
+	require.Equal(t, "dfp is back", history[0].Reason)
+
+	s = New("", bpdbBackend, nil, nil, &conf, nil, "", false, NewMockS3Uploader()).(*server)
+	history, err = bpdbBackend.BlacklistHistory()
+	require.Nil(t, err)
+	require.Len(t, history, 3, "config patterns are only imported into an unchanged blacklist")
+	require.False(t, testBlacklistName(t, s, "dfp_ads").Blacklisted)
+}
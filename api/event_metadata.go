@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+	"github.com/zenazn/goji/web"
+)
+
+const (
+	maxRetentionDays = 3650
+	minSLATier       = 1
+	maxSLATier       = 3
+)
+
+// oncallContactRe matches an email address, a Slack handle or a Slack channel.
+var oncallContactRe = regexp.MustCompile(`^([@#][\w.-]+|[^@\s]+@[^@\s]+\.[^@\s]+)$`)
+
+// metadataValidator returns an error if value is not valid for its metadata type.
+type metadataValidator func(value string) error
+
+// newMetadataValidators returns the validators of the event metadata types
+// that can be updated. If teams is not empty, only teams in it can own events.
+// Kinesis streams named as datastores must be among kinesisConfigs.
+func newMetadataValidators(teams []string,
+	kinesisConfigs func() ([]scoop_protocol.AnnotatedKinesisConfig, error)) map[scoop_protocol.EventMetadataType]metadataValidator {
+	return map[scoop_protocol.EventMetadataType]metadataValidator{
+		scoop_protocol.EDGE_TYPE:  oneOf("internal", "external"),
+		scoop_protocol.COMMENT:    func(string) error { return nil },
+		scoop_protocol.DATASTORES: datastoresValidator(kinesisConfigs),
+		bpdb.REQUIRED_PROPERTIES:  validateRequiredProperties,
+		bpdb.OWNER_TEAM:           teamValidator(teams),
+		bpdb.ONCALL_CONTACT:       validateOncallContact,
+		bpdb.RETENTION_DAYS:       integerRange(1, maxRetentionDays),
+		bpdb.SLA_TIER:             integerRange(minSLATier, maxSLATier),
+	}
+}
+
+func oneOf(values ...string) metadataValidator {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid metadata value %q, expected one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+func integerRange(min, max int) metadataValidator {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < min || i > max {
+			return fmt.Errorf("Invalid metadata value %q, expected an integer from %d to %d", value, min, max)
+		}
+		return nil
+	}
+}
+
+func teamValidator(teams []string) metadataValidator {
+	if len(teams) == 0 {
+		return func(value string) error {
+			if strings.TrimSpace(value) == "" {
+				return errors.New("Invalid metadata value, team is required")
+			}
+			return nil
+		}
+	}
+	return oneOf(teams...)
+}
+
+func datastoresValidator(kinesisConfigs func() ([]scoop_protocol.AnnotatedKinesisConfig, error)) metadataValidator {
+	return func(value string) error {
+		normalized, err := bpdb.NormalizeDatastores(value)
+		if err != nil {
+			return fmt.Errorf("Invalid metadata value: %v", err)
+		}
+		if len(bpdb.KinesisDatastoreStreams(normalized)) == 0 {
+			return nil
+		}
+		configs, err := kinesisConfigs()
+		if err != nil {
+			return fmt.Errorf("getting Kinesis configs to validate datastores: %v", err)
+		}
+		if err = bpdb.ValidateDatastoreStreams(normalized, configs); err != nil {
+			return fmt.Errorf("Invalid metadata value: %v", err)
+		}
+		return nil
+	}
+}
+
+// kinesisConfigs returns every Kinesis config, for validating datastores.
+func (s *server) kinesisConfigs() ([]scoop_protocol.AnnotatedKinesisConfig, error) {
+	if s.bpKinesisConfigBackend == nil {
+		return nil, errors.New("no Kinesis config backend")
+	}
+	return s.bpKinesisConfigBackend.AllKinesisConfigs()
+}
+
+// canonicalMetadataValue returns the value to store for validated metadata:
+// datastores are stored as a normalized set, anything else as given.
+func canonicalMetadataValue(metadataType scoop_protocol.EventMetadataType, value string) string {
+	if metadataType == scoop_protocol.DATASTORES {
+		if normalized, err := bpdb.NormalizeDatastores(value); err == nil {
+			return normalized
+		}
+	}
+	return value
+}
+
+func validateOncallContact(value string) error {
+	if !oncallContactRe.MatchString(value) {
+		return fmt.Errorf("Invalid metadata value %q, expected an email address, Slack handle or Slack channel", value)
+	}
+	return nil
+}
+
+func validateRequiredProperties(value string) error {
+	names := bpdb.ParseRequiredProperties(value)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return fmt.Errorf("Invalid metadata value, %s is required more than once", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+func (s *server) validateEventMetadataUpdate(metadataType scoop_protocol.EventMetadataType, metadataValue string) error {
+	if metadataType == scoop_protocol.BIRTH {
+		return errors.New("Birth metadata is set when the event is created and cannot be updated")
+	}
+	validate, ok := s.metadataValidators[metadataType]
+	if !ok {
+		return errors.New("This metadata type has not yet been implemented")
+	}
+	return validate(metadataValue)
+}
+
+func (s *server) eventMetadataHistory(c web.C, w http.ResponseWriter, r *http.Request) {
+	eventName := c.URLParams["event"]
+	schema, err := s.bpSchemaBackend.Schema(eventName, nil)
+	if err != nil {
+		logger.WithError(err).WithField("schema", eventName).Error("Error retrieving schema")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if schema == nil {
+		fourOhFour(w, r)
+		return
+	}
+	history, err := s.bpSchemaBackend.EventMetadataHistory(eventName)
+	if err != nil {
+		logger.WithError(err).WithField("schema", eventName).Error("Error retrieving event metadata history")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeStructToResponse(w, history)
+}
+
+// revertEventMetadataHelper restores the value the metadata had at the
+// requested version, storing it as a new version.
+func (s *server) revertEventMetadataHelper(eventName string, body io.ReadCloser, user string) *core.WebError {
+	var req core.ClientRevertEventMetadataRequest
+	if err := decodeBody(body, &req); err != nil {
+		return core.NewUserWebError(err)
+	}
+	req.EventName = eventName
+	history, err := s.bpSchemaBackend.EventMetadataHistory(eventName)
+	if err != nil {
+		return core.NewServerWebErrorf("getting event metadata history: %v", err)
+	}
+	var target, current *bpdb.EventMetadataChange
+	for i := range history {
+		change := &history[i]
+		if change.MetadataType != string(req.MetadataType) {
+			continue
+		}
+		if current == nil || change.Version > current.Version {
+			current = change
+		}
+		if change.Version == req.Version {
+			target = change
+		}
+	}
+	if target == nil {
+		return core.NewUserWebErrorf("%s metadata of %s has no version %d", req.MetadataType, eventName, req.Version)
+	}
+	if target == current {
+		return core.NewUserWebErrorf("version %d is already the current %s metadata of %s", req.Version, req.MetadataType, eventName)
+	}
+	if err = s.validateEventMetadataUpdate(req.MetadataType, target.MetadataValue); err != nil {
+		return core.NewUserWebErrorf("cannot restore version %d: %v", req.Version, err)
+	}
+	webErr := s.bpSchemaBackend.UpdateEventMetadata(&core.ClientUpdateEventMetadataRequest{
+		EventName:     eventName,
+		MetadataType:  req.MetadataType,
+		MetadataValue: canonicalMetadataValue(req.MetadataType, target.MetadataValue),
+	}, user)
+	if webErr != nil {
+		return webErr
+	}
+	logger.WithField("schema", eventName).
+		WithField("metadata_type", req.MetadataType).
+		WithField("version", req.Version).
+		Info("Event metadata reverted")
+	return nil
+}
+
+func (s *server) revertEventMetadata(c web.C, w http.ResponseWriter, r *http.Request) {
+	webErr := s.revertEventMetadataHelper(c.URLParams["event"], r.Body, c.Env["username"].(string))
+	if webErr != nil {
+		webErr.ReportError(w, "Error reverting event metadata")
+		return
+	}
+	s.goCache.Delete(allMetadataCache)
+	_, err := s.getAndPublishEventMetadata()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all metadata")
+	}
+}
+
+// datastores lists the registry of datastores DATASTORES metadata can name.
+func (s *server) datastores(w http.ResponseWriter, r *http.Request) {
+	writeStructToResponse(w, bpdb.Datastores)
+}
+
+// metadataOwnedBy returns the metadata of the events owned by team.
+func metadataOwnedBy(metadata map[string](map[string]bpdb.EventMetadataRow), team string) map[string](map[string]bpdb.EventMetadataRow) {
+	owned := make(map[string](map[string]bpdb.EventMetadataRow))
+	for eventName, eventMetadata := range metadata {
+		if eventMetadata[string(bpdb.OWNER_TEAM)].MetadataValue == team {
+			owned[eventName] = eventMetadata
+		}
+	}
+	return owned
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+	"github.com/zenazn/goji/web"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/blueprint/test"
+)
+
+func TestValidateEventMetadataUpdate(t *testing.T) {
+	require := require.New(t)
+	conf := Config{Teams: []string{"video", "chat"}}
+	s := New("", nil, nil, nil, &conf, nil, "", false, NewMockS3Uploader()).(*server)
+
+	valid := map[scoop_protocol.EventMetadataType][]string{
+		scoop_protocol.EDGE_TYPE:  {"internal", "external"},
+		scoop_protocol.DATASTORES: {"", "ace,tahoe", "redshift,s3_archive", `["Redshift"]`},
+		bpdb.OWNER_TEAM:           {"video", "chat"},
+		bpdb.ONCALL_CONTACT:       {"video-oncall@example.com", "@jdoe", "#video-alerts"},
+		bpdb.RETENTION_DAYS:       {"1", "90", "3650"},
+		bpdb.SLA_TIER:             {"1", "3"},
+	}
+	for metadataType, values := range valid {
+		for _, value := range values {
+			require.Nil(s.validateEventMetadataUpdate(metadataType, value), "%s %s", metadataType, value)
+		}
+	}
+	invalid := map[scoop_protocol.EventMetadataType][]string{
+		scoop_protocol.EDGE_TYPE:  {"", "both"},
+		scoop_protocol.DATASTORES: {"mysql", "kinesis", "kinesis:missing"},
+		bpdb.OWNER_TEAM:           {"", "ads"},
+		bpdb.ONCALL_CONTACT:       {"", "jdoe", "@", "jdoe@example"},
+		bpdb.RETENTION_DAYS:       {"", "0", "3651", "90d"},
+		bpdb.SLA_TIER:             {"0", "4", "gold"},
+		bpdb.REQUIRED_PROPERTIES:  {"a,b,a"},
+		scoop_protocol.BIRTH:      {"2017-01-01T00:00:00+0000"},
+		"unknown":                 {"x"},
+	}
+	for metadataType, values := range invalid {
+		for _, value := range values {
+			require.NotNil(s.validateEventMetadataUpdate(metadataType, value), "%s %s", metadataType, value)
+		}
+	}
+
+	s = New("", nil, nil, nil, &Config{}, nil, "", false, NewMockS3Uploader()).(*server)
+	require.Nil(s.validateEventMetadataUpdate(bpdb.OWNER_TEAM, "ads"), "any team is allowed without a registry")
+	require.NotNil(s.validateEventMetadataUpdate(bpdb.OWNER_TEAM, " "))
+}
+
+func TestUpdateEventMetadataNormalizesDatastores(t *testing.T) {
+	require := require.New(t)
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{"this-event-exists": {}})
+	s := New("", nil, schemaBackend, test.NewMockBpKinesisConfigBackend(), &config, nil, "", false, NewMockS3Uploader()).(*server)
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": "jdoe"},
+		URLParams: map[string]string{"event": "this-event-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/metadata/this-event-exists",
+		strings.NewReader(`{"MetadataType": "datastores", "MetadataValue": "tahoe, Redshift ,ace"}`))
+	recorder := httptest.NewRecorder()
+	s.updateEventMetadata(c, recorder, req)
+	require.Equal(http.StatusOK, recorder.Code)
+	metadata, err := s.metadataByEvent()
+	require.Nil(err)
+	require.Equal("ace,redshift,tahoe", metadata["this-event-exists"]["datastores"].MetadataValue)
+
+	req, _ = http.NewRequest("POST", "/metadata/this-event-exists",
+		strings.NewReader(`{"MetadataType": "datastores", "MetadataValue": "ace,kinesis:missing"}`))
+	recorder = httptest.NewRecorder()
+	s.updateEventMetadata(c, recorder, req)
+	require.NotEqual(http.StatusOK, recorder.Code, "Kinesis streams must have a Kinesis config")
+}
+
+func TestAllEventMetadataByOwner(t *testing.T) {
+	require := require.New(t)
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{
+		"video_play":   {"owner_team": {MetadataValue: "video"}, "comment": {MetadataValue: "plays"}},
+		"video_pause":  {"owner_team": {MetadataValue: "video"}},
+		"chat_message": {"owner_team": {MetadataValue: "chat"}},
+		"orphan":       {"comment": {MetadataValue: "nobody owns this"}},
+	})
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, NewMockS3Uploader()).(*server)
+
+	get := func(url string) map[string]map[string]bpdb.EventMetadataRow {
+		req, _ := http.NewRequest("GET", url, nil)
+		recorder := httptest.NewRecorder()
+		s.allEventMetadata(recorder, req)
+		require.Equal(http.StatusOK, recorder.Code)
+		var metadata map[string]map[string]bpdb.EventMetadataRow
+		require.Nil(json.Unmarshal(recorder.Body.Bytes(), &metadata))
+		return metadata
+	}
+	require.Len(get("/allmetadata"), 4)
+	owned := get("/allmetadata?owner=video")
+	require.Len(owned, 2)
+	require.Equal("plays", owned["video_play"]["comment"].MetadataValue)
+	require.Contains(owned, "video_pause")
+	require.Empty(get("/allmetadata?owner=ads"))
+}
+
+func TestRevertEventMetadata(t *testing.T) {
+	require := require.New(t)
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{"this-event-exists": {}})
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, NewMockS3Uploader()).(*server)
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": "jdoe"},
+		URLParams: map[string]string{"event": "this-event-exists"},
+	}
+	for _, comment := range []string{"original comment", "overwritten by mistake"} {
+		require.Nil(schemaBackend.UpdateEventMetadata(&core.ClientUpdateEventMetadataRequest{
+			EventName:     "this-event-exists",
+			MetadataType:  scoop_protocol.COMMENT,
+			MetadataValue: comment,
+		}, "someone"))
+	}
+
+	revert := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/metadata/this-event-exists/revert", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		s.revertEventMetadata(c, recorder, req)
+		return recorder
+	}
+	require.Equal(http.StatusBadRequest, revert(`{"MetadataType": "comment", "Version": 5}`).Code)
+	require.Equal(http.StatusBadRequest, revert(`{"MetadataType": "comment", "Version": 2}`).Code,
+		"the current version cannot be restored")
+	require.Equal(http.StatusOK, revert(`{"MetadataType": "comment", "Version": 1}`).Code)
+
+	req, _ := http.NewRequest("GET", "/metadata/this-event-exists/history", nil)
+	recorder := httptest.NewRecorder()
+	s.eventMetadataHistory(c, recorder, req)
+	require.Equal(http.StatusOK, recorder.Code)
+	var history []bpdb.EventMetadataChange
+	require.Nil(json.Unmarshal(recorder.Body.Bytes(), &history))
+	require.Len(history, 3)
+	require.Equal(bpdb.EventMetadataChange{
+		MetadataType:  "comment",
+		MetadataValue: "original comment",
+		UserName:      "jdoe",
+		Version:       3,
+	}, history[0])
+
+	c.URLParams["event"] = "missing"
+	recorder = httptest.NewRecorder()
+	s.eventMetadataHistory(c, recorder, req)
+	require.Equal(http.StatusNotFound, recorder.Code)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"regexp"
@@ -21,6 +22,7 @@ import (
 	"github.com/twitchscience/aws_utils/logger"
 	"github.com/twitchscience/blueprint/bpdb"
 	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/blueprint/export"
 	"github.com/twitchscience/blueprint/ingester"
 	"github.com/twitchscience/scoop_protocol/scoop_protocol"
 	"github.com/twitchscience/scoop_protocol/transformer"
@@ -32,14 +34,29 @@ const (
 	schemaConfigS3Key        = "schema-configs.json.gz"
 	kinesisConfigS3Key       = "kinesis-configs.json.gz"
 	eventMetadataConfigS3Key = "event-metadata-configs.json.gz"
+	avroSchemasS3Key         = "avro-schemas.json.gz"
+	parquetSchemasS3Key      = "parquet-schemas.json.gz"
+
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
+
+	defaultGoPackage = "events"
 )
 
+var goPackageRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
 // Config configures the API's webserver.
 type Config struct {
-	CacheTimeoutSecs      int      `json:"cacheTimeoutSecs"`
-	S3BpConfigsBucketName string   `json:"s3BPConfigsBucketName"`
-	S3BpConfigsPrefix     string   `json:"s3BPConfigsPrefix"`
-	Blacklist             []string `json:"blacklist"`
+	CacheTimeoutSecs      int                        `json:"cacheTimeoutSecs"`
+	S3BpConfigsBucketName string                     `json:"s3BPConfigsBucketName"`
+	S3BpConfigsPrefix     string                     `json:"s3BPConfigsPrefix"`
+	// Blacklist seeds the blacklist the first time blueprint starts against
+	// a db; afterwards it is managed through the /blacklist endpoints.
+	Blacklist             []string                   `json:"blacklist"`
+	Lint                  bpdb.LintConfig            `json:"lint"`
+	EventNamePolicy       bpdb.EventNamePolicyConfig `json:"eventNamePolicy"`
+	// Teams are the teams that can own events. If empty, any team can.
+	Teams                 []string                   `json:"teams"`
 }
 
 type maintenanceMode struct {
@@ -51,16 +68,19 @@ func (s *server) loadConfig(conf *Config) error {
 	s.cacheTimeout = time.Duration(conf.CacheTimeoutSecs) * time.Second
 	s.s3BpConfigsBucketName = conf.S3BpConfigsBucketName
 	s.s3BpConfigsPrefix = conf.S3BpConfigsPrefix
-	blacklist := conf.Blacklist
-
-	for _, pattern := range blacklist {
-		re, err := regexp.Compile(strings.ToLower(pattern))
-		if err != nil {
-			return err
-		}
-		s.blacklistRe = append(s.blacklistRe, re)
+	linter, err := bpdb.NewLinter(conf.Lint)
+	if err != nil {
+		return fmt.Errorf("configuring lint: %v", err)
 	}
-	return nil
+	s.linter = linter
+	policy, err := bpdb.NewEventNamePolicy(conf.EventNamePolicy)
+	if err != nil {
+		return fmt.Errorf("configuring event name policy: %v", err)
+	}
+	s.eventNamePolicy = policy
+	s.metadataValidators = newMetadataValidators(conf.Teams, s.kinesisConfigs)
+	s.blacklistRe, err = compileBlacklist(conf.Blacklist)
+	return err
 }
 
 // respondWithJSONError responds with a JSON error with the given error code. The format of the
@@ -217,11 +237,44 @@ func (s *server) getAndPublishSchemas() ([]bpdb.AnnotatedSchema, error) {
 	if err != nil {
 		return nil, err
 	}
+	docs, err := s.bpSchemaBackend.AllColumnDocs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schemas {
+		bpdb.AttachColumnDocs(&schemas[i], docs[schemas[i].EventName])
+	}
 	s.goCache.Set(allSchemasCache, schemas, s.cacheTimeout)
 	publishToS3(s.s3Uploader, schemas, s.s3BpConfigsBucketName, schemaConfigS3Key, s.s3BpConfigsPrefix)
+	s.publishDataLakeSchemas(schemas)
 	return schemas, nil
 }
 
+// publishDataLakeSchemas publishes the Avro and Parquet schemas of every
+// schema, by event name, for the data lake. Schemas that cannot be exported
+// are logged and left out.
+func (s *server) publishDataLakeSchemas(schemas []bpdb.AnnotatedSchema) {
+	avroSchemas := make(map[string]*export.AvroSchema, len(schemas))
+	parquetSchemas := make(map[string]string, len(schemas))
+	for i := range schemas {
+		schema := &schemas[i]
+		avro, err := export.SchemaAvro(schema)
+		if err != nil {
+			logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate Avro schema")
+			continue
+		}
+		parquet, err := export.SchemaParquet(schema)
+		if err != nil {
+			logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate Parquet schema")
+			continue
+		}
+		avroSchemas[schema.EventName] = avro
+		parquetSchemas[schema.EventName] = parquet
+	}
+	publishToS3(s.s3Uploader, avroSchemas, s.s3BpConfigsBucketName, avroSchemasS3Key, s.s3BpConfigsPrefix)
+	publishToS3(s.s3Uploader, parquetSchemas, s.s3BpConfigsBucketName, parquetSchemasS3Key, s.s3BpConfigsPrefix)
+}
+
 func (s *server) getAndPublishEventMetadata() (*bpdb.AllEventMetadata, error) {
 	allMetadata, err := s.bpSchemaBackend.AllEventMetadata()
 	if err != nil {
@@ -243,7 +296,17 @@ func (s *server) getAndPublishKinesisConfigs() ([]scoop_protocol.AnnotatedKinesi
 }
 
 func (s *server) createSchema(c web.C, w http.ResponseWriter, r *http.Request) {
-	webErr := s.createSchemaHelper(c.Env["username"].(string), r.Body)
+	if isDryRun(r) {
+		preview, webErr := s.previewCreateSchemaHelper(r.Body)
+		if webErr != nil {
+			webErr.ReportError(w, "Error previewing schema creation")
+			return
+		}
+		writePreviewToResponse(w, preview)
+		return
+	}
+
+	report, webErr := s.createSchemaHelper(c.Env["username"].(string), r.Body)
 	if webErr != nil {
 		webErr.ReportError(w, "Error creating schema")
 		return
@@ -253,40 +316,266 @@ func (s *server) createSchema(c web.C, w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.WithError(err).Error("Failed to retrieve all schemas")
 	}
+	writeStructToResponse(w, report)
 }
 
-func (s *server) createSchemaHelper(username string, body io.ReadCloser) *core.WebError {
-	var cfg scoop_protocol.Config
+func (s *server) decodeCreateSchemaRequest(body io.ReadCloser) (*core.ClientCreateSchemaRequest, *core.WebError) {
+	var cfg core.ClientCreateSchemaRequest
 	err := decodeBody(body, &cfg)
+	if err != nil {
+		return nil, core.NewServerWebError(err)
+	}
+
+	if webErr := s.checkNewEventName(cfg.EventName, cfg.OwnerTeam); webErr != nil {
+		return nil, webErr
+	}
+	return &cfg, nil
+}
+
+// lintReport returns the lint results for the columns of a change, or a
+// user error if any of them are errors.
+func lintReport(eventName string, results []bpdb.LintResult) (*bpdb.LintReport, *core.WebError) {
+	if err := bpdb.LintErrors(results); err != nil {
+		return nil, core.NewUserWebError(err)
+	}
+	return &bpdb.LintReport{EventName: eventName, Results: results}, nil
+}
+
+func (s *server) createSchemaHelper(username string, body io.ReadCloser) (*bpdb.LintReport, *core.WebError) {
+	cfg, webErr := s.decodeCreateSchemaRequest(body)
+	if webErr != nil {
+		return nil, webErr
+	}
+	report, webErr := lintReport(cfg.EventName, s.linter.LintColumns(cfg.Columns))
+	if webErr != nil {
+		return nil, webErr
+	}
+	return report, s.bpSchemaBackend.CreateSchema(cfg, username)
+}
+
+func (s *server) previewCreateSchemaHelper(body io.ReadCloser) (*bpdb.SchemaPreview, *core.WebError) {
+	cfg, webErr := s.decodeCreateSchemaRequest(body)
+	if webErr != nil {
+		return nil, webErr
+	}
+	report, webErr := lintReport(cfg.EventName, s.linter.LintColumns(cfg.Columns))
+	if webErr != nil {
+		return nil, webErr
+	}
+	preview, webErr := s.bpSchemaBackend.PreviewCreateSchema(cfg)
+	if webErr != nil {
+		return nil, webErr
+	}
+	preview.Lint = report.Results
+	return preview, nil
+}
+
+// eventNameViolations checks the name of a new event to be owned by `team`
+// against the naming policy.
+func (s *server) eventNameViolations(name, team string) ([]bpdb.EventNameViolation, error) {
+	if s.eventNamePolicy.Empty() {
+		return []bpdb.EventNameViolation{}, nil
+	}
+	schemas, err := s.bpSchemaBackend.AllSchemas()
+	if err != nil {
+		return nil, err
+	}
+	existing := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		existing = append(existing, schema.EventName)
+	}
+	return s.eventNamePolicy.Check(name, team, existing), nil
+}
+
+// checkNewEventName returns an error if the name is blacklisted, the owning
+// team is not valid or the name breaks the naming policy for that team.
+func (s *server) checkNewEventName(name, team string) *core.WebError {
+	if s.isBlacklisted(name) {
+		return core.NewUserWebErrorf("%s is blacklisted", name)
+	}
+	if team != "" {
+		if err := s.metadataValidators[bpdb.OWNER_TEAM](team); err != nil {
+			return core.NewUserWebErrorf("owner team invalid: %v", err)
+		}
+	}
+	violations, err := s.eventNameViolations(name, team)
+	if err != nil {
+		return core.NewServerWebErrorf("checking event name policy: %v", err)
+	}
+	return core.NewUserWebError(bpdb.EventNameViolationsError(name, violations))
+}
+
+// eventNameCheck is the result of checking a new event name.
+type eventNameCheck struct {
+	EventName   string
+	Blacklisted bool
+	Violations  []bpdb.EventNameViolation
+}
+
+func (s *server) checkEventName(c web.C, w http.ResponseWriter, r *http.Request) {
+	name := c.URLParams["name"]
+	violations, err := s.eventNameViolations(name, r.URL.Query().Get("team"))
+	if err != nil {
+		logger.WithError(err).Error("Failed to check event name policy")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeStructToResponse(w, eventNameCheck{
+		EventName:   name,
+		Blacklisted: s.isBlacklisted(name),
+		Violations:  violations,
+	})
+}
+
+func (s *server) cloneSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	webErr := s.cloneSchemaHelper(c.URLParams["id"], c.Env["username"].(string), r.Body)
+	if webErr != nil {
+		webErr.ReportError(w, "Error cloning schema")
+		return
+	}
+	s.goCache.Delete(allSchemasCache)
+	_, err := s.getAndPublishSchemas()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all schemas")
+	}
+}
+
+func (s *server) cloneSchemaHelper(sourceEventName string, username string, body io.ReadCloser) *core.WebError {
+	var req core.ClientCloneSchemaRequest
+	err := decodeBody(body, &req)
 	if err != nil {
 		return core.NewServerWebError(err)
 	}
+	req.SourceEventName = sourceEventName
 
-	if s.isBlacklisted(cfg.EventName) {
-		return core.NewUserWebErrorf("%s is blacklisted", cfg.EventName)
+	if webErr := s.checkNewEventName(req.EventName, req.OwnerTeam); webErr != nil {
+		return webErr
+	}
+	source, err := s.bpSchemaBackend.Schema(sourceEventName, nil)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting schema to clone: %v", err)
 	}
-	return s.bpSchemaBackend.CreateSchema(&cfg, username)
+	if source == nil {
+		return core.NewUserWebErrorf("schema to clone does not exist")
+	}
+	cfg, err := bpdb.CloneConfig(source, &req)
+	if err != nil {
+		return core.NewUserWebError(err)
+	}
+	if _, webErr := lintReport(cfg.EventName, s.linter.LintColumns(cfg.Columns)); webErr != nil {
+		return webErr
+	}
+	return s.bpSchemaBackend.CloneSchema(&req, username)
 }
 
-// isBlacklisted check whether name matches any regex in the blacklist (case insensitive).
-// It returns false when name is not blacklisted or an error occurs.
-func (s *server) isBlacklisted(name string) bool {
-	name = strings.ToLower(name)
-	for _, pattern := range s.blacklistRe {
-		if pattern.MatchString(name) {
-			return true
+func (s *server) bulkUpdateSchemas(c web.C, w http.ResponseWriter, r *http.Request) {
+	var entries []core.ClientBulkUpdateSchemaEntry
+	err := decodeBody(r.Body, &entries)
+	if err != nil {
+		core.NewServerWebError(err).ReportError(w, "decoding bulk update request")
+		return
+	}
+
+	reqs := make([]*core.ClientUpdateSchemaRequest, 0, len(entries))
+	results := make([]bpdb.BulkUpdateResult, 0, len(entries))
+	inMaintenance := false
+	invalid := false
+	for i := range entries {
+		req := &entries[i].ClientUpdateSchemaRequest
+		req.EventName = entries[i].EventName
+		reqs = append(reqs, req)
+
+		result := bpdb.BulkUpdateResult{EventName: req.EventName, Lint: s.linter.LintUpdate(req)}
+		if err := bpdb.LintErrors(result.Lint); err != nil {
+			result.Error = err.Error()
+			invalid = true
+		}
+		mm, err := s.bpdbBackend.GetSchemaMaintenanceMode(req.EventName)
+		if err != nil {
+			logger.WithField("schema", req.EventName).WithField("error", err).Error("Could not check schema maintenance mode")
+			respondWithJSONError(w, fmt.Sprintf("Could not check maintenance mode for schema %s", req.EventName),
+				http.StatusInternalServerError)
+			return
+		}
+		if mm.IsInMaintenanceMode {
+			result.Error = fmt.Sprintf("Schema %s is in maintenance mode; no modifications are allowed", req.EventName)
+			inMaintenance = true
+		}
+		results = append(results, result)
+	}
+	if inMaintenance {
+		writeBulkResults(w, http.StatusServiceUnavailable, results)
+		return
+	}
+	if invalid {
+		writeBulkResults(w, http.StatusBadRequest, results)
+		return
+	}
+
+	lint := results
+	results, webErr := s.bpSchemaBackend.UpdateSchemas(reqs, c.Env["username"].(string))
+	if webErr != nil {
+		webErr.ReportError(w, "Error updating schemas")
+		return
+	}
+	for i := range results {
+		results[i].Lint = lint[i].Lint
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			writeBulkResults(w, http.StatusBadRequest, results)
+			return
 		}
 	}
-	return false
+	s.goCache.Delete(allSchemasCache)
+	_, err = s.getAndPublishSchemas()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all schemas")
+	}
+	writeBulkResults(w, http.StatusOK, results)
+}
+
+func writeBulkResults(w http.ResponseWriter, responseCode int, results []bpdb.BulkUpdateResult) {
+	js, err := json.Marshal(results)
+	if err != nil {
+		logger.WithError(err).Error("Failed to marshal JSON")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseCode)
+	_, err = w.Write(js)
+	if err != nil {
+		logger.WithError(err).Error("Failed to write JSON to response")
+	}
 }
 
 func (s *server) updateSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaWithForce(c, w, r, false)
+}
+
+// forceUpdateSchema updates a schema like updateSchema, but allows deleting
+// columns that have not been deprecated past their sunset.
+func (s *server) forceUpdateSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	s.updateSchemaWithForce(c, w, r, true)
+}
+
+func (s *server) updateSchemaWithForce(c web.C, w http.ResponseWriter, r *http.Request, force bool) {
 	eventName := c.URLParams["id"]
+	if isDryRun(r) {
+		preview, webErr := s.previewUpdateSchemaHelper(eventName, r.Body, r.Header.Get("If-Match"), force)
+		if webErr != nil {
+			webErr.ReportError(w, "Error previewing schema update")
+			return
+		}
+		writePreviewToResponse(w, preview)
+		return
+	}
 	if s.maintenanceModeGuard(eventName, w) {
 		return // error written by maintenanceModeGuard
 	}
 
-	webErr := s.updateSchemaHelper(eventName, c.Env["username"].(string), r.Body)
+	report, webErr := s.updateSchemaHelper(eventName, c.Env["username"].(string), r.Body, r.Header.Get("If-Match"), force)
 	if webErr != nil {
 		webErr.ReportError(w, "Error updating schema")
 		return
@@ -296,16 +585,111 @@ func (s *server) updateSchema(c web.C, w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		logger.WithError(err).Error("Failed to retrieve all schemas")
 	}
+	writeStructToResponse(w, report)
 }
 
-func (s *server) updateSchemaHelper(eventName string, username string, body io.ReadCloser) *core.WebError {
+func decodeUpdateSchemaRequest(eventName string, body io.ReadCloser, ifMatch string, force bool) (*core.ClientUpdateSchemaRequest, *core.WebError) {
 	var req core.ClientUpdateSchemaRequest
 	err := decodeBody(body, &req)
 	if err != nil {
-		return core.NewServerWebError(err)
+		return nil, core.NewServerWebError(err)
 	}
 	req.EventName = eventName
-	return s.bpSchemaBackend.UpdateSchema(&req, username)
+	req.ForceDelete = force
+	if ifMatch != "" {
+		version, err := parseVersionETag(ifMatch)
+		if err != nil {
+			return nil, core.NewUserWebError(err)
+		}
+		if req.BaseVersion != nil && *req.BaseVersion != version {
+			return nil, core.NewUserWebErrorf("If-Match header (%d) and BaseVersion (%d) disagree", version, *req.BaseVersion)
+		}
+		req.BaseVersion = &version
+	}
+	return &req, nil
+}
+
+func (s *server) updateSchemaHelper(eventName string, username string, body io.ReadCloser, ifMatch string, force bool) (*bpdb.LintReport, *core.WebError) {
+	req, webErr := decodeUpdateSchemaRequest(eventName, body, ifMatch, force)
+	if webErr != nil {
+		return nil, webErr
+	}
+	report, webErr := lintReport(eventName, s.linter.LintUpdate(req))
+	if webErr != nil {
+		return nil, webErr
+	}
+	if force && len(req.Deletes) > 0 {
+		logger.WithField("schema", eventName).
+			WithField("user", username).
+			WithField("deletes", req.Deletes).
+			Warn("Deleting columns without waiting for deprecation")
+	}
+	return report, s.bpSchemaBackend.UpdateSchema(req, username)
+}
+
+func (s *server) previewUpdateSchemaHelper(eventName string, body io.ReadCloser, ifMatch string, force bool) (*bpdb.SchemaPreview, *core.WebError) {
+	req, webErr := decodeUpdateSchemaRequest(eventName, body, ifMatch, force)
+	if webErr != nil {
+		return nil, webErr
+	}
+	report, webErr := lintReport(eventName, s.linter.LintUpdate(req))
+	if webErr != nil {
+		return nil, webErr
+	}
+	preview, webErr := s.bpSchemaBackend.PreviewUpdateSchema(req)
+	if webErr != nil {
+		return nil, webErr
+	}
+	preview.Lint = report.Results
+	return preview, nil
+}
+
+func (s *server) revertSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	s.revertSchemaWithForce(c, w, r, false)
+}
+
+// forceRevertSchema reverts a schema like revertSchema, but allows deleting
+// columns that have not been deprecated past their sunset.
+func (s *server) forceRevertSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	s.revertSchemaWithForce(c, w, r, true)
+}
+
+func (s *server) revertSchemaWithForce(c web.C, w http.ResponseWriter, r *http.Request, force bool) {
+	eventName := c.URLParams["id"]
+	if s.maintenanceModeGuard(eventName, w) {
+		return // error written by maintenanceModeGuard
+	}
+
+	webErr := s.revertSchemaHelper(eventName, c.Env["username"].(string), r.Body, force)
+	if webErr != nil {
+		webErr.ReportError(w, "Error reverting schema")
+		return
+	}
+	s.goCache.Delete(allSchemasCache)
+	_, err := s.getAndPublishSchemas()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all schemas")
+	}
+}
+
+func (s *server) revertSchemaHelper(eventName string, username string, body io.ReadCloser, force bool) *core.WebError {
+	var req struct {
+		ToVersion *int
+	}
+	err := decodeBody(body, &req)
+	if err != nil {
+		return core.NewUserWebError(err)
+	}
+	if req.ToVersion == nil {
+		return core.NewUserWebErrorf("ToVersion is required")
+	}
+	if force {
+		logger.WithField("schema", eventName).
+			WithField("user", username).
+			WithField("to_version", *req.ToVersion).
+			Warn("Reverting without waiting for deprecation of deleted columns")
+	}
+	return s.bpSchemaBackend.RevertSchema(eventName, *req.ToVersion, username, force)
 }
 
 func (s *server) dropSchema(c web.C, w http.ResponseWriter, r *http.Request) {
@@ -363,6 +747,78 @@ func (s *server) dropSchema(c web.C, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *server) restoreSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	username := c.Env["username"].(string)
+	var req core.ClientRestoreSchemaRequest
+	err := decodeBody(r.Body, &req)
+	if err != nil {
+		core.NewServerWebError(err).ReportError(w, "decoding restore schema request")
+		return
+	}
+
+	// Validate before touching the ingester, so a bad request doesn't
+	// increment its version.
+	preview, webErr := s.bpSchemaBackend.PreviewRestoreSchema(req.EventName, req.Reason)
+	if webErr != nil {
+		webErr.ReportError(w, "Error restoring schema")
+		return
+	}
+	if isDryRun(r) {
+		writePreviewToResponse(w, preview)
+		return
+	}
+
+	if s.maintenanceModeGuard(req.EventName, w) {
+		return // error written by maintenanceModeGuard
+	}
+
+	err = s.ingesterController.IncrementVersion(req.EventName)
+	if err != nil {
+		core.NewServerWebError(err).ReportError(w, "incrementing version in ingester")
+		return
+	}
+
+	webErr = s.bpSchemaBackend.RestoreSchema(req.EventName, req.Reason, username)
+	if webErr != nil {
+		webErr.ReportError(w, "Error restoring schema")
+		return
+	}
+	s.goCache.Delete(allSchemasCache)
+	_, err = s.getAndPublishSchemas()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all schemas")
+	}
+}
+
+func (s *server) snapshotDrift(w http.ResponseWriter, r *http.Request) {
+	drift, err := s.bpSchemaBackend.SnapshotDrift()
+	if err != nil {
+		logger.WithError(err).Error("Error checking schema snapshots")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeStructToResponse(w, drift)
+}
+
+func (s *server) rebuildSnapshots(w http.ResponseWriter, r *http.Request) {
+	drift, err := s.bpSchemaBackend.RebuildSnapshots()
+	if err != nil {
+		logger.WithError(err).Error("Error rebuilding schema snapshots")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(drift) > 0 {
+		s.goCache.Delete(allSchemasCache)
+		_, err = s.getAndPublishSchemas()
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve all schemas")
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeStructToResponse(w, drift)
+}
+
 func (s *server) allSchemas(w http.ResponseWriter, r *http.Request) {
 	cachedSchemas, found := s.goCache.Get(allSchemasCache)
 	if found {
@@ -379,7 +835,10 @@ func (s *server) allSchemas(w http.ResponseWriter, r *http.Request) {
 	writeStructToResponse(w, schemas)
 }
 
-func (s *server) schema(c web.C, w http.ResponseWriter, r *http.Request) {
+// requestedSchema returns the schema in the URL, at the version in the query
+// arguments if one is given. If it returns nil, it has written an error to
+// the response.
+func (s *server) requestedSchema(c web.C, w http.ResponseWriter, r *http.Request) *bpdb.AnnotatedSchema {
 	var schema *bpdb.AnnotatedSchema
 	var err error
 	var version int
@@ -394,22 +853,255 @@ func (s *server) schema(c web.C, w http.ResponseWriter, r *http.Request) {
 			logger.WithError(err).
 				WithField("version", versionStr).
 				Warning("'version' must be non-negative integer")
-			return
+			return nil
 		}
 		schema, err = s.bpSchemaBackend.Schema(event, &version)
 	}
 	if err != nil {
 		logger.WithError(err).WithField("schema", event).Error("Error retrieving schema")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil
 	}
 	if schema == nil {
 		fourOhFour(w, r)
+		return nil
+	}
+	return schema
+}
+
+func (s *server) schema(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
 		return
 	}
+	docs, err := s.bpSchemaBackend.ColumnDocs(schema.EventName)
+	if err != nil {
+		logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to get column docs")
+		respondWithJSONError(w, "Internal Service Error", http.StatusInternalServerError)
+		return
+	}
+	bpdb.AttachColumnDocs(schema, docs)
+	w.Header().Set("ETag", versionETag(schema.Version))
 	writeStructToResponse(w, []*bpdb.AnnotatedSchema{schema})
 }
 
+func (s *server) schemaDDL(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	ddl, err := export.CreateTableDDL(schema)
+	if err != nil {
+		logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate DDL")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeTextToResponse(w, ddl)
+}
+
+func (s *server) schemaAvro(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	avro, err := export.SchemaAvro(schema)
+	if err != nil {
+		logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate Avro schema")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeStructToResponse(w, avro)
+}
+
+func (s *server) schemaParquet(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	parquet, err := export.SchemaParquet(schema)
+	if err != nil {
+		logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate Parquet schema")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeTextToResponse(w, parquet)
+}
+
+// goPackageName returns the package name for generated Go code from the
+// query arguments, or writes an error and returns "" if it is invalid.
+func goPackageName(w http.ResponseWriter, r *http.Request) string {
+	pkg := r.URL.Query().Get("package")
+	if pkg == "" {
+		return defaultGoPackage
+	}
+	if !goPackageRe.MatchString(pkg) {
+		respondWithJSONError(w, "Error, 'package' argument must be a lower case Go identifier.", http.StatusBadRequest)
+		return ""
+	}
+	return pkg
+}
+
+func writeGoStructsToResponse(w http.ResponseWriter, pkg string, schemas []*bpdb.AnnotatedSchema) {
+	src, err := export.GoStructs(pkg, schemas)
+	if err != nil {
+		logger.WithError(err).Error("Failed to generate Go structs")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeTextToResponse(w, string(src))
+}
+
+func (s *server) schemaGoStruct(c web.C, w http.ResponseWriter, r *http.Request) {
+	pkg := goPackageName(w, r)
+	if pkg == "" {
+		return
+	}
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	writeGoStructsToResponse(w, pkg, []*bpdb.AnnotatedSchema{schema})
+}
+
+func (s *server) allGoStructs(w http.ResponseWriter, r *http.Request) {
+	pkg := goPackageName(w, r)
+	if pkg == "" {
+		return
+	}
+	var schemas []bpdb.AnnotatedSchema
+	cachedSchemas, found := s.goCache.Get(allSchemasCache)
+	if found {
+		schemas = cachedSchemas.([]bpdb.AnnotatedSchema)
+	} else {
+		var err error
+		schemas, err = s.getAndPublishSchemas()
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve all schemas")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	ptrs := make([]*bpdb.AnnotatedSchema, len(schemas))
+	for i := range schemas {
+		ptrs[i] = &schemas[i]
+	}
+	writeGoStructsToResponse(w, pkg, ptrs)
+}
+
+func (s *server) validateEvents(c web.C, w http.ResponseWriter, r *http.Request) {
+	events, err := decodeSampleEvents(r.Body)
+	if err != nil {
+		respondWithJSONError(w, fmt.Sprintf("Error decoding sample events: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(events) == 0 {
+		respondWithJSONError(w, "Error, no sample events given.", http.StatusBadRequest)
+		return
+	}
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	writeStructToResponse(w, bpdb.ValidateEvents(schema, events))
+}
+
+func (s *server) lintSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	writeStructToResponse(w, bpdb.LintReport{
+		EventName: schema.EventName,
+		Results:   s.linter.LintColumns(schema.Columns),
+	})
+}
+
+// metadataByEvent returns the metadata of every event, from the cache if possible.
+func (s *server) metadataByEvent() (map[string](map[string]bpdb.EventMetadataRow), error) {
+	cachedMetadata, found := s.goCache.Get(allMetadataCache)
+	if found {
+		return cachedMetadata.(map[string](map[string]bpdb.EventMetadataRow)), nil
+	}
+	allMetadata, err := s.getAndPublishEventMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return allMetadata.Metadata, nil
+}
+
+func (s *server) schemaJSONSchema(c web.C, w http.ResponseWriter, r *http.Request) {
+	schema := s.requestedSchema(c, w, r)
+	if schema == nil {
+		return
+	}
+	metadata, err := s.metadataByEvent()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all metadata")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	doc, err := export.SchemaJSONSchema(schema, export.RequiredProperties(metadata[schema.EventName]))
+	if err != nil {
+		logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate JSON Schema")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeStructToResponse(w, doc)
+}
+
+func (s *server) allJSONSchemas(w http.ResponseWriter, r *http.Request) {
+	var schemas []bpdb.AnnotatedSchema
+	cachedSchemas, found := s.goCache.Get(allSchemasCache)
+	if found {
+		schemas = cachedSchemas.([]bpdb.AnnotatedSchema)
+	} else {
+		var err error
+		schemas, err = s.getAndPublishSchemas()
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve all schemas")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	metadata, err := s.metadataByEvent()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all metadata")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	docs := make(map[string]*export.JSONSchema, len(schemas))
+	for i := range schemas {
+		schema := &schemas[i]
+		doc, err := export.SchemaJSONSchema(schema, export.RequiredProperties(metadata[schema.EventName]))
+		if err != nil {
+			logger.WithError(err).WithField("schema", schema.EventName).Error("Failed to generate JSON Schema")
+			respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		docs[schema.EventName] = doc
+	}
+	writeStructToResponse(w, docs)
+}
+
+// sensitiveColumns lists every column classified as PII, for audits.
+func (s *server) sensitiveColumns(w http.ResponseWriter, r *http.Request) {
+	var schemas []bpdb.AnnotatedSchema
+	cachedSchemas, found := s.goCache.Get(allSchemasCache)
+	if found {
+		schemas = cachedSchemas.([]bpdb.AnnotatedSchema)
+	} else {
+		var err error
+		schemas, err = s.getAndPublishSchemas()
+		if err != nil {
+			logger.WithError(err).Error("Failed to retrieve all schemas")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeStructToResponse(w, bpdb.SensitiveColumns(schemas))
+}
+
 func respondWithJSONBool(w http.ResponseWriter, key string, result bool) {
 	js, err := json.Marshal(map[string]bool{key: result})
 	if err != nil {
@@ -453,20 +1145,18 @@ func (s *server) droppableSchema(c web.C, w http.ResponseWriter, r *http.Request
 	respondWithJSONBool(w, "Droppable", !exists)
 }
 
+// allEventMetadata returns the metadata of every event, or of the events
+// owned by the team in the "owner" query argument.
 func (s *server) allEventMetadata(w http.ResponseWriter, r *http.Request) {
-	cachedMetadata, found := s.goCache.Get(allMetadataCache)
-	if found {
-		writeStructToResponse(w, cachedMetadata)
-		return
-	}
-
-	allMetadata, err := s.getAndPublishEventMetadata()
+	metadata, err := s.metadataByEvent()
 	if err != nil {
 		logger.WithError(err).Error("Failed to retrieve all metadata")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	metadata := allMetadata.Metadata
+	if owner := r.URL.Query().Get("owner"); owner != "" {
+		metadata = metadataOwnedBy(metadata, owner)
+	}
 	writeStructToResponse(w, metadata)
 }
 
@@ -522,11 +1212,12 @@ func (s *server) updateEventMetadata(c web.C, w http.ResponseWriter, r *http.Req
 	}
 
 	req.EventName = eventName
-	err = validateEventMetadataUpdate(req.MetadataType, req.MetadataValue)
+	err = s.validateEventMetadataUpdate(req.MetadataType, req.MetadataValue)
 	if err != nil {
 		core.NewServerWebError(err).ReportError(w, "Update event metadata validation error")
 		return
 	}
+	req.MetadataValue = canonicalMetadataValue(req.MetadataType, req.MetadataValue)
 
 	webErr := s.bpSchemaBackend.UpdateEventMetadata(&req, c.Env["username"].(string))
 	if webErr != nil {
@@ -540,8 +1231,33 @@ func (s *server) updateEventMetadata(c web.C, w http.ResponseWriter, r *http.Req
 	}
 }
 
-func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
-	var from int
+func (s *server) updateColumnDoc(c web.C, w http.ResponseWriter, r *http.Request) {
+	var req core.ClientUpdateColumnDocRequest
+	err := decodeBody(r.Body, &req)
+	if err != nil {
+		core.NewUserWebError(err).ReportError(w, "Error decoding request body")
+		return
+	}
+	req.EventName = c.URLParams["id"]
+	req.Column = c.URLParams["column"]
+
+	webErr := s.bpSchemaBackend.UpdateColumnDoc(&req, c.Env["username"].(string))
+	if webErr != nil {
+		webErr.ReportError(w, "Error updating column doc")
+		return
+	}
+	s.goCache.Delete(allSchemasCache)
+	_, err = s.getAndPublishSchemas()
+	if err != nil {
+		logger.WithError(err).Error("Failed to retrieve all schemas")
+	}
+}
+
+// migrationOperations returns the operations migrating the schema in the URL
+// between the versions in the query arguments. Versions that only changed
+// column metadata have an empty migration. If it returns nil, it has written
+// an error to the response.
+func (s *server) migrationOperations(c web.C, w http.ResponseWriter, r *http.Request) (operations []*scoop_protocol.Operation, from int) {
 	args := r.URL.Query()
 	to, err := strconv.Atoi(args.Get("to_version"))
 	if err != nil || to < 0 {
@@ -549,7 +1265,7 @@ func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
 		logger.WithError(err).
 			WithField("to_version", args.Get("to_version")).
 			Warning("'to_version' must be non-negative integer")
-		return
+		return nil, 0
 	}
 	fromStr := args.Get("from_version")
 	if fromStr == "" {
@@ -561,10 +1277,10 @@ func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
 			logger.WithError(err).
 				WithField("from_version", args.Get("from_version")).
 				Warning("'from_version' must be non-negative integer")
-			return
+			return nil, 0
 		}
 	}
-	operations, err := s.bpSchemaBackend.Migration(
+	operations, err = s.bpSchemaBackend.Migration(
 		c.URLParams["schema"],
 		from,
 		to,
@@ -572,10 +1288,41 @@ func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		respondWithJSONError(w, "Internal Service Error", http.StatusInternalServerError)
 		logger.WithError(err).Error("Failed to get migration steps")
-		return
+		return nil, 0
 	}
 	if len(operations) == 0 {
-		respondWithJSONError(w, fmt.Sprintf("No migration for table '%s' to v%d.", c.URLParams["schema"], to), http.StatusBadRequest)
+		schema, err := s.bpSchemaBackend.Schema(c.URLParams["schema"], nil)
+		if err != nil {
+			respondWithJSONError(w, "Internal Service Error", http.StatusInternalServerError)
+			logger.WithError(err).Error("Failed to get schema to check migration")
+			return nil, 0
+		}
+		if schema == nil || from >= to || to > schema.Version {
+			respondWithJSONError(w, fmt.Sprintf("No migration for table '%s' to v%d.", c.URLParams["schema"], to), http.StatusBadRequest)
+			return nil, 0
+		}
+		return []*scoop_protocol.Operation{}, from
+	}
+	return operations, from
+}
+
+func (s *server) migrationDDL(c web.C, w http.ResponseWriter, r *http.Request) {
+	operations, from := s.migrationOperations(c, w, r)
+	if operations == nil {
+		return
+	}
+	ddl, err := export.MigrationDDL(c.URLParams["schema"], from, operations)
+	if err != nil {
+		logger.WithError(err).WithField("schema", c.URLParams["schema"]).Error("Failed to generate migration DDL")
+		respondWithJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeTextToResponse(w, ddl)
+}
+
+func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
+	operations, _ := s.migrationOperations(c, w, r)
+	if operations == nil {
 		return
 	}
 	b, err := json.Marshal(operations)
@@ -592,6 +1339,82 @@ func (s *server) migration(c web.C, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *server) schemaDiff(c web.C, w http.ResponseWriter, r *http.Request) {
+	args := r.URL.Query()
+	from, err := strconv.Atoi(args.Get("from"))
+	if err != nil || from < -1 {
+		respondWithJSONError(w, "Error, 'from' argument must be an integer greater or equal to -1.", http.StatusBadRequest)
+		logger.WithError(err).
+			WithField("from", args.Get("from")).
+			Warning("'from' must be an integer greater or equal to -1")
+		return
+	}
+	to, err := strconv.Atoi(args.Get("to"))
+	if err != nil || to <= from {
+		respondWithJSONError(w, "Error, 'to' argument must be an integer greater than 'from'.", http.StatusBadRequest)
+		logger.WithError(err).
+			WithField("to", args.Get("to")).
+			Warning("'to' must be an integer greater than 'from'")
+		return
+	}
+	event := c.URLParams["id"]
+	diff, err := s.bpSchemaBackend.SchemaDiff(event, from, to)
+	if err != nil {
+		logger.WithError(err).WithField("schema", event).Error("Error diffing schema")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if diff == nil {
+		fourOhFour(w, r)
+		return
+	}
+	if args.Get("format") == "text" {
+		writeTextToResponse(w, diff.UnifiedText())
+		return
+	}
+	writeStructToResponse(w, diff)
+}
+
+// parsePagingArg parses a non-negative integer query argument, returning
+// `def` if it is absent.
+func parsePagingArg(args url.Values, name string, def int) (int, error) {
+	str := args.Get(name)
+	if str == "" {
+		return def, nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("'%s' argument must be non-negative integer", name)
+	}
+	return val, nil
+}
+
+func (s *server) schemaHistory(c web.C, w http.ResponseWriter, r *http.Request) {
+	args := r.URL.Query()
+	offset, err := parsePagingArg(args, "offset", 0)
+	if err != nil {
+		respondWithJSONError(w, "Error, "+err.Error()+".", http.StatusBadRequest)
+		return
+	}
+	limit, err := parsePagingArg(args, "limit", defaultHistoryPageSize)
+	if err != nil || limit == 0 || limit > maxHistoryPageSize {
+		respondWithJSONError(w, fmt.Sprintf("Error, 'limit' argument must be between 1 and %d.", maxHistoryPageSize), http.StatusBadRequest)
+		return
+	}
+	event := c.URLParams["id"]
+	history, err := s.bpSchemaBackend.SchemaHistory(event, offset, limit)
+	if err != nil {
+		logger.WithError(err).WithField("schema", event).Error("Error retrieving schema history")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if history == nil {
+		fourOhFour(w, r)
+		return
+	}
+	writeStructToResponse(w, history)
+}
+
 func (s *server) fileHandler(w http.ResponseWriter, r *http.Request) {
 	fname := staticPath(s.docRoot, r.URL.Path)
 	fh, err := os.Open(fname)
@@ -55,6 +55,79 @@ func TestMigrationNegativeTo(t *testing.T) {
 	assertNotPublishedToS3(t, "TestMigrationNegativeTo", s3Uploader)
 }
 
+func TestMigrationOnlyMetadata(t *testing.T) {
+	s := New("", nil, test.NewMockBpSchemaBackend(nil), nil, &config, nil, "", false, NewMockS3Uploader()).(*server)
+	c := web.C{URLParams: map[string]string{"schema": "this-legacy-table-exists"}}
+
+	req, _ := http.NewRequest("GET", "/migration/this-legacy-table-exists?to_version=1", nil)
+	recorder := httptest.NewRecorder()
+	s.migration(c, recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code, "a version that only changed column metadata has an empty migration")
+	require.Equal(t, "[]", recorder.Body.String())
+
+	req, _ = http.NewRequest("GET", "/migration/this-legacy-table-exists?to_version=2", nil)
+	recorder = httptest.NewRecorder()
+	s.migration(c, recorder, req)
+	require.Equal(t, http.StatusBadRequest, recorder.Code, "versions after the current one have no migration")
+}
+
+func TestSchemaDiffInvalidTo(t *testing.T) {
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, nil, nil, &config, nil, "", false, s3Uploader).(*server)
+	handler := web.HandlerFunc(s.schemaDiff)
+	recorder := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/schema/testerino/diff?from=4&to=3", nil)
+	handler.ServeHTTP(recorder, req)
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+	assertNotPublishedToS3(t, "TestSchemaDiffInvalidTo", s3Uploader)
+}
+
+func TestSchemaHistoryInvalidLimit(t *testing.T) {
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, nil, nil, &config, nil, "", false, s3Uploader).(*server)
+	handler := web.HandlerFunc(s.schemaHistory)
+	recorder := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/schema/testerino/history?limit=0", nil)
+	handler.ServeHTTP(recorder, req)
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestSchemaGoStructInvalidPackage(t *testing.T) {
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, nil, nil, &config, nil, "", false, s3Uploader).(*server)
+	handler := web.HandlerFunc(s.schemaGoStruct)
+	recorder := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("GET", "/schema/testerino/gostruct?package=Events", nil)
+	handler.ServeHTTP(recorder, req)
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
+func TestValidateEventsNotObjects(t *testing.T) {
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, nil, nil, &config, nil, "", false, s3Uploader).(*server)
+	handler := web.HandlerFunc(s.validateEvents)
+	recorder := httptest.NewRecorder()
+
+	req, _ := http.NewRequest("POST", "/schema/testerino/validate", strings.NewReader(`[{"time": 1}, 5]`))
+	handler.ServeHTTP(recorder, req)
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusBadRequest)
+	}
+}
+
 func TestAllSchemasCache(t *testing.T) {
 	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
 	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{"event": {}})
@@ -518,6 +591,223 @@ func TestSchemaNegativeVersion(t *testing.T) {
 	assertNotPublishedToS3(t, "TestSchemaNegativeVersion", s3Uploader)
 }
 
+func TestRevertSchemaMissingVersion(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", bpdbBackend, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists/revert", strings.NewReader("{}"))
+	s.revertSchema(c, recorder, req)
+
+	assertRequestBad(t, "TestRevertSchemaMissingVersion", recorder, "Error reverting schema: ToVersion is required")
+	assertNotPublishedToS3(t, "TestRevertSchemaMissingVersion", s3Uploader)
+}
+
+func TestCloneSchemaBlacklisted(t *testing.T) {
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, nil, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists/clone", strings.NewReader(`{"EventName": "dfp_clone"}`))
+	s.cloneSchema(c, recorder, req)
+
+	assertRequestBad(t, "TestCloneSchemaBlacklisted", recorder, "Error cloning schema: dfp_clone is blacklisted")
+	assertNotPublishedToS3(t, "TestCloneSchemaBlacklisted", s3Uploader)
+}
+
+func TestCloneSchemaEventNamePolicy(t *testing.T) {
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+	policy, err := bpdb.NewEventNamePolicy(bpdb.EventNamePolicyConfig{
+		BannedSuffixes: []string{"_test"},
+		MaxLength:      12,
+	})
+	require.Nil(t, err)
+	s.eventNamePolicy = policy
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists/clone", strings.NewReader(`{"EventName": "video_play_test"}`))
+	s.cloneSchema(c, recorder, req)
+
+	assertRequestBad(t, "TestCloneSchemaEventNamePolicy", recorder,
+		"Error cloning schema: event name video_play_test breaks naming policy: "+
+			"must not end with _test (banned_suffix); is 15 characters long, the maximum is 12 (max_length)")
+	assertNotPublishedToS3(t, "TestCloneSchemaEventNamePolicy", s3Uploader)
+}
+
+func TestCloneSchemaTeamPrefix(t *testing.T) {
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+	policy, err := bpdb.NewEventNamePolicy(bpdb.EventNamePolicyConfig{
+		RequiredPrefixes: map[string][]string{"video": {"video_"}, "chat": {"chat_"}},
+	})
+	require.Nil(t, err)
+	s.eventNamePolicy = policy
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists/clone",
+		strings.NewReader(`{"EventName": "video_seek", "OwnerTeam": "chat"}`))
+	s.cloneSchema(c, recorder, req)
+	assertRequestBad(t, "TestCloneSchemaTeamPrefix", recorder,
+		"Error cloning schema: event name video_seek breaks naming policy: "+
+			"must start with a prefix of team chat, one of chat_ (required_prefix)")
+
+	recorder = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/schema/this-table-exists/clone",
+		strings.NewReader(`{"EventName": "video_seek", "OwnerTeam": "video"}`))
+	s.cloneSchema(c, recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestCloneSchemaLintError(t *testing.T) {
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-legacy-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-legacy-table-exists/clone", strings.NewReader(`{"EventName": "legacy_clone"}`))
+	s.cloneSchema(c, recorder, req)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "user is a Redshift reserved word (reserved_word)")
+	assertNotPublishedToS3(t, "TestCloneSchemaLintError", s3Uploader)
+
+	recorder = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/schema/this-legacy-table-exists/clone",
+		strings.NewReader(`{"EventName": "legacy_clone", "Exclude": ["user"]}`))
+	s.cloneSchema(c, recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestUpdateColumnDoc(t *testing.T) {
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", nil, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": "analyst"},
+		URLParams: map[string]string{"id": "this-table-does-not-exist", "column": "quality"},
+	}
+	body := `{"Description": "Video quality the player chose", "Examples": ["720p60"], "Owner": "video"}`
+	req, _ := http.NewRequest("POST", "/schema/this-table-does-not-exist/column/quality/doc", strings.NewReader(body))
+	s.updateColumnDoc(c, recorder, req)
+	assertRequestBad(t, "TestUpdateColumnDoc", recorder, "Error updating column doc: schema does not exist")
+
+	recorder = httptest.NewRecorder()
+	c.URLParams["id"] = "this-table-exists"
+	req, _ = http.NewRequest("POST", "/schema/this-table-exists/column/quality/doc", strings.NewReader(body))
+	s.updateColumnDoc(c, recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	docs, err := schemaBackend.ColumnDocs("this-table-exists")
+	require.Nil(t, err)
+	require.Equal(t, bpdb.ColumnDoc{
+		Description: "Video quality the player chose",
+		Examples:    []string{"720p60"},
+		Owner:       "video",
+		UserName:    "analyst",
+		Version:     1,
+	}, docs["quality"])
+}
+
+func TestUpdateSchemaIfMatchDisagrees(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", bpdbBackend, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists", strings.NewReader(`{"BaseVersion": 3}`))
+	req.Header.Set("If-Match", `W/"4"`)
+	s.updateSchema(c, recorder, req)
+
+	assertRequestBad(t, "TestUpdateSchemaIfMatchDisagrees", recorder,
+		"Error updating schema: If-Match header (4) and BaseVersion (3) disagree")
+	assertNotPublishedToS3(t, "TestUpdateSchemaIfMatchDisagrees", s3Uploader)
+}
+
+func TestUpdateSchemaDryRun(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", bpdbBackend, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+	s.s3BpConfigsBucketName = "test-bucket"
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists?dry_run=true", strings.NewReader(`{"Deletes": ["a"]}`))
+	s.updateSchema(c, recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var preview bpdb.SchemaPreview
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &preview))
+	assert.Equal(t, "this-table-exists", preview.EventName)
+	assertNotPublishedToS3(t, "TestUpdateSchemaDryRun", s3Uploader)
+}
+
+func TestUpdateSchemaLintError(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", bpdbBackend, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+	s.s3BpConfigsBucketName = "test-bucket"
+
+	recorder := httptest.NewRecorder()
+	c := web.C{
+		Env:       map[interface{}]interface{}{"username": ""},
+		URLParams: map[string]string{"id": "this-table-exists"},
+	}
+	req, _ := http.NewRequest("POST", "/schema/this-table-exists",
+		strings.NewReader(`{"Additions": [{"InboundName": "user", "OutboundName": "user", "Transformer": "bigint"}]}`))
+	s.updateSchema(c, recorder, req)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "user is a Redshift reserved word (reserved_word)")
+	assertNotPublishedToS3(t, "TestUpdateSchemaLintError", s3Uploader)
+}
+
+func TestParseVersionETag(t *testing.T) {
+	for _, etag := range []string{`"7"`, `W/"7"`, "7"} {
+		version, err := parseVersionETag(etag)
+		require.Nil(t, err)
+		assert.Equal(t, 7, version)
+	}
+	_, err := parseVersionETag(`"abc"`)
+	assert.NotNil(t, err)
+	assert.Equal(t, `"12"`, versionETag(12))
+}
+
 func TestSchemaMaintenanceGet(t *testing.T) {
 	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{
 		"in-maintenance": {IsInMaintenanceMode: true, User: "bob"},
@@ -584,6 +874,29 @@ func TestUpdateDuringSchemaMaintenance(t *testing.T) {
 	assertNotPublishedToS3(t, "TestUpdateDuringSchemaMaintenance", s3Uploader)
 }
 
+func TestBulkUpdateDuringSchemaMaintenance(t *testing.T) {
+	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{
+		"starts-in-maintenance": {IsInMaintenanceMode: true, User: "bob"},
+	}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
+	schemaBackend := test.NewMockBpSchemaBackend(map[string]map[string]bpdb.EventMetadataRow{})
+	s3Uploader := NewMockS3Uploader()
+	s := New("", bpdbBackend, schemaBackend, nil, &config, nil, "", false, s3Uploader).(*server)
+
+	recorder := httptest.NewRecorder()
+	c := web.C{Env: map[interface{}]interface{}{"username": ""}}
+	req, _ := http.NewRequest("POST", "/schemas/bulk", strings.NewReader(
+		`[{"EventName": "this-table-exists", "Deletes": ["a"]}, {"EventName": "starts-in-maintenance", "Deletes": ["a"]}]`))
+	s.bulkUpdateSchemas(c, recorder, req)
+
+	assertRequest503(t, "TestBulkUpdateDuringSchemaMaintenance", recorder)
+	var results []bpdb.BulkUpdateResult
+	require.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, "", results[0].Error)
+	assert.Equal(t, "Schema starts-in-maintenance is in maintenance mode; no modifications are allowed", results[1].Error)
+	assertNotPublishedToS3(t, "TestBulkUpdateDuringSchemaMaintenance", s3Uploader)
+}
+
 func TestUpdateDuringGlobalMaintenance(t *testing.T) {
 	bpdbBackend := test.NewMockBpdb(map[string]bpdb.MaintenanceMode{}, []*bpdb.ActiveUser{}, []*bpdb.DailyChange{})
 	err := bpdbBackend.SetMaintenanceMode(true, "test", "because I'm an automated test.")
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,11 +10,12 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/twitchscience/aws_utils/logger"
-	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+	"github.com/twitchscience/blueprint/bpdb"
 )
 
 // SchemaSuggestion indicates a schema for an event that has occurred a certain number of times.
@@ -49,6 +50,27 @@ func writeStructToResponse(w http.ResponseWriter, events interface{}) {
 	}
 }
 
+// writeTextToResponse writes plain text, such as generated code, to the response.
+func writeTextToResponse(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err := io.WriteString(w, text)
+	if err != nil {
+		logger.WithError(err).Error("Failed to write to response")
+	}
+}
+
+// isDryRun returns whether the request asks for a preview of its changes
+// rather than for them to be made.
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dry_run") == "true"
+}
+
+// writePreviewToResponse writes a schema preview as JSON.
+func writePreviewToResponse(w http.ResponseWriter, preview *bpdb.SchemaPreview) {
+	w.Header().Set("Content-Type", "application/json")
+	writeStructToResponse(w, preview)
+}
+
 func jsonResponse(h http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -58,10 +80,11 @@ func jsonResponse(h http.Handler) http.Handler {
 }
 
 func getS3ConfigsFileName(baseFileName string, prefix string) (string, error) {
-	if baseFileName != schemaConfigS3Key && baseFileName != kinesisConfigS3Key && baseFileName != eventMetadataConfigS3Key {
-		return "", fmt.Errorf("Invalid base config key %s", baseFileName)
+	switch baseFileName {
+	case schemaConfigS3Key, kinesisConfigS3Key, eventMetadataConfigS3Key, avroSchemasS3Key, parquetSchemasS3Key:
+		return prefix + "-" + baseFileName, nil
 	}
-	return prefix + "-" + baseFileName, nil
+	return "", fmt.Errorf("Invalid base config key %s", baseFileName)
 }
 
 func getNewSuggestion(docRoot string, name string) (newSuggestion SchemaSuggestion, err error) {
@@ -116,25 +139,6 @@ func validSuggestion(suggestion, docRoot string) bool {
 	return false
 }
 
-func validateEventMetadataUpdate(metadataType scoop_protocol.EventMetadataType, metadataValue string) error {
-	var notImplementedError = errors.New("This metadata type has not yet been implemented")
-	var invalidValueError = errors.New("Invalid metadata value")
-
-	switch metadataType {
-	case scoop_protocol.EDGE_TYPE:
-		if metadataValue == "internal" || metadataValue == "external" {
-			return nil
-		}
-		return invalidValueError
-	case scoop_protocol.COMMENT:
-		return nil
-	case scoop_protocol.DATASTORES:
-		return nil
-	default:
-		return notImplementedError
-	}
-}
-
 func (s *server) requestTableDeletion(schemaName string, reason string, username string) (err error) {
 	v := url.Values{}
 	v.Set("table", schemaName)
@@ -178,3 +182,48 @@ func decodeBody(body io.ReadCloser, requestObj interface{}) error {
 	}
 	return nil
 }
+
+// decodeSampleEvents decodes either a single event's properties or an array
+// of them, keeping numbers as json.Number so integers can be told apart from floats.
+func decodeSampleEvents(body io.ReadCloser) ([]map[string]interface{}, error) {
+	var raw json.RawMessage
+	err := decodeBody(body, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var events []map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = decoder.Decode(&events)
+	} else {
+		var event map[string]interface{}
+		err = decoder.Decode(&event)
+		events = append(events, event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding events: %v", err)
+	}
+	for i, event := range events {
+		if event == nil {
+			return nil, fmt.Errorf("event %d is not an object", i)
+		}
+	}
+	return events, nil
+}
+
+// versionETag returns the ETag for the given schema version.
+func versionETag(version int) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// parseVersionETag parses a schema version from an If-Match header, as set
+// by versionETag. Weak ETags are accepted, since versions are immutable.
+func parseVersionETag(etag string) (int, error) {
+	trimmed := strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+	version, err := strconv.Atoi(trimmed)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("If-Match header must be a schema version, given %s", etag)
+	}
+	return version, nil
+}
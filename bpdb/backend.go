@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,7 +15,6 @@ import (
 
 var (
 	maxColumns               = 300
-	keyNames                 = []string{"distkey", "sortkey"}
 	blacklistedOutboundNames = []string{"date"}
 	timeColName              = "time"
 )
@@ -30,6 +30,29 @@ type AnnotatedSchema struct {
 	Dropped       bool
 	DropRequested bool
 	Reason        string
+
+	// Deprecations are the deprecated columns, by outbound name.
+	Deprecations map[string]ColumnDeprecation
+
+	// PII are the PII classes of the sensitive columns, by outbound name.
+	// Columns not listed are classified core.PIINone.
+	PII map[string]string
+
+	// ColumnDocs are the docs of the documented columns, by outbound name.
+	// They are stored apart from the operation log and attached by the API.
+	ColumnDocs map[string]ColumnDoc
+}
+
+// ColumnDeprecation is why a column is deprecated and when it may be deleted.
+type ColumnDeprecation struct {
+	Reason string
+	Sunset string
+}
+
+// SunsetPassed returns whether the column may be deleted at time `now`.
+func (d ColumnDeprecation) SunsetPassed(now time.Time) bool {
+	sunset, err := time.Parse(core.SunsetFormat, d.Sunset)
+	return err == nil && !now.Before(sunset)
 }
 
 // ActiveUser is a count of the number of changes a user has made.
@@ -59,6 +82,15 @@ type EventMetadata struct {
 	Metadata  map[string]EventMetadataRow // The key in the map is a MetadataType
 }
 
+// EventMetadataChange is one version of one type of an event's metadata.
+type EventMetadataChange struct {
+	MetadataType  string
+	MetadataValue string
+	TS            time.Time
+	UserName      string
+	Version       int
+}
+
 // MaintenanceMode represents a maintenance mode state and user that created
 // that state
 type MaintenanceMode struct {
@@ -66,6 +98,17 @@ type MaintenanceMode struct {
 	User                string
 }
 
+// BlacklistChange is a change to the event name blacklist: a pattern being
+// added to it or removed from it.
+type BlacklistChange struct {
+	Version     int
+	Pattern     string
+	Blacklisted bool
+	User        string
+	Reason      string
+	TS          time.Time
+}
+
 // AllEventMetadata is the metadata for all events
 type AllEventMetadata struct {
 	Metadata map[string](map[string]EventMetadataRow)
@@ -79,6 +122,10 @@ type Bpdb interface {
 	DailyChangesLast30Days() ([]*DailyChange, error)
 	GetSchemaMaintenanceMode(string) (MaintenanceMode, error)
 	SetSchemaMaintenanceMode(schema string, switchingOn bool, user, reason string) error
+	BlacklistPatterns() ([]BlacklistChange, error)
+	BlacklistHistory() ([]BlacklistChange, error)
+	SetBlacklistPattern(pattern string, blacklisted bool, user, reason string) error
+	ImportBlacklistPatterns(patterns []string, user, reason string) error
 }
 
 // BpSchemaBackend is the interface of the blueprint db backend that stores schema state
@@ -86,11 +133,26 @@ type BpSchemaBackend interface {
 	AllSchemas() ([]AnnotatedSchema, error)
 	Schema(name string, version *int) (*AnnotatedSchema, error)
 	UpdateSchema(update *core.ClientUpdateSchemaRequest, user string) *core.WebError
-	CreateSchema(schema *scoop_protocol.Config, user string) *core.WebError
+	UpdateSchemas(updates []*core.ClientUpdateSchemaRequest, user string) ([]BulkUpdateResult, *core.WebError)
+	RevertSchema(eventName string, toVersion int, user string, force bool) *core.WebError
+	CreateSchema(schema *core.ClientCreateSchemaRequest, user string) *core.WebError
+	PreviewUpdateSchema(update *core.ClientUpdateSchemaRequest) (*SchemaPreview, *core.WebError)
+	PreviewCreateSchema(schema *core.ClientCreateSchemaRequest) (*SchemaPreview, *core.WebError)
 	Migration(table string, from int, to int) ([]*scoop_protocol.Operation, error)
+	SchemaDiff(name string, from int, to int) (*SchemaDiff, error)
+	SchemaHistory(name string, offset int, limit int) (*SchemaHistory, error)
 	DropSchema(schema *AnnotatedSchema, reason string, exists bool, user string) error
+	PreviewRestoreSchema(eventName string, reason string) (*SchemaPreview, *core.WebError)
+	RestoreSchema(eventName string, reason string, user string) *core.WebError
+	CloneSchema(req *core.ClientCloneSchemaRequest, user string) *core.WebError
+	SnapshotDrift() ([]SnapshotDrift, error)
+	RebuildSnapshots() ([]SnapshotDrift, error)
 	AllEventMetadata() (*AllEventMetadata, error)
 	UpdateEventMetadata(req *core.ClientUpdateEventMetadataRequest, user string) *core.WebError
+	EventMetadataHistory(eventName string) ([]EventMetadataChange, error)
+	ColumnDocs(eventName string) (map[string]ColumnDoc, error)
+	AllColumnDocs() (map[string]map[string]ColumnDoc, error)
+	UpdateColumnDoc(req *core.ClientUpdateColumnDocRequest, user string) *core.WebError
 }
 
 // BpKinesisConfigBackend is the interface of the blueprint db backend that stores kinesis config state
@@ -143,10 +205,41 @@ func validateOutboundName(name string) error {
 }
 
 func validateIsNotKey(options string) error {
-	for _, keyName := range keyNames {
-		if strings.Contains(options, keyName) {
-			return fmt.Errorf("this column is %s", keyName)
+	opts := core.ColumnOptionsFromString(options)
+	if opts.DistKey {
+		return errors.New("this column is distkey")
+	}
+	if opts.SortKey {
+		return errors.New("this column is sortkey")
+	}
+	return nil
+}
+
+// validateRetype returns an error unless changing a column from the old type
+// to the new one is a widening that can be applied in place: a longer
+// varchar, int to bigint, or a more precise float. The encoding cannot be
+// changed in place, so it must be kept as it is.
+func validateRetype(oldType string, oldOptions core.ColumnOptions, newType string, newOptions core.ColumnOptions) error {
+	if newOptions.Encoding != oldOptions.Encoding {
+		return fmt.Errorf("encoding cannot be changed, given %q to %q", oldOptions.Encoding, newOptions.Encoding)
+	}
+	switch {
+	case oldType == "varchar" && newType == "varchar":
+		oldLength, newLength := oldOptions.VarcharLength(), newOptions.VarcharLength()
+		if newLength <= oldLength {
+			return fmt.Errorf("varchar length can only be increased, given %d to %d", oldLength, newLength)
+		}
+	case oldType == "int" && newType == "bigint":
+		if newOptions.Length > 0 {
+			return fmt.Errorf("bigint does not take a length, given (%d)", newOptions.Length)
+		}
+	case oldType == "float" && newType == "float":
+		oldPrecision, newPrecision := oldOptions.FloatPrecision(), newOptions.FloatPrecision()
+		if newPrecision <= oldPrecision {
+			return fmt.Errorf("float precision can only be increased, given %d to %d", oldPrecision, newPrecision)
 		}
+	default:
+		return fmt.Errorf("cannot change type from %s to %s, only widening varchar, int to bigint and float precision are allowed", oldType, newType)
 	}
 	return nil
 }
@@ -174,12 +267,19 @@ func preValidateSchema(schema *scoop_protocol.Config) error {
 		if err != nil {
 			return fmt.Errorf("column transformer invalid: %v", err)
 		}
+		opts, err := core.ParseColumnOptions(col.ColumnCreationOptions)
+		if err == nil {
+			err = opts.Validate(col.Transformer)
+		}
+		if err != nil {
+			return fmt.Errorf("column options invalid for %s: %v", col.OutboundName, err)
+		}
 	}
 	err = validateHasTime(schema.Columns)
 	if err != nil {
 		return err
 	}
-	ops := schemaCreateRequestToOps(schema)
+	ops := schemaCreateRequestToOps(schema, nil)
 	err = ApplyOperations(&AnnotatedSchema{}, ops)
 	if err != nil {
 		return err
@@ -190,25 +290,110 @@ func preValidateSchema(schema *scoop_protocol.Config) error {
 	return nil
 }
 
-// schemaCreateRequestToOps converts a schema update request into a list of add operations
-func schemaCreateRequestToOps(req *scoop_protocol.Config) []scoop_protocol.Operation {
+// schemaCreateRequestToOps converts a schema create request into a list of add
+// operations, classifying the columns with the given PII classes.
+func schemaCreateRequestToOps(req *scoop_protocol.Config, pii map[string]string) []scoop_protocol.Operation {
 	ops := make([]scoop_protocol.Operation, 0, len(req.Columns))
 	for _, col := range req.Columns {
-		ops = append(ops, scoop_protocol.NewAddOperation(col.OutboundName, col.InboundName,
-			col.Transformer, col.ColumnCreationOptions, col.SupportingColumns))
+		ops = append(ops, newAddOperation(col, pii[col.OutboundName]))
 	}
 	return ops
 }
 
+// newAddOperation returns an operation adding the column with the given PII
+// class, or the default class of its transformer if none is given. The class
+// is always recorded so that changing the defaults does not reclassify
+// existing columns.
+func newAddOperation(col scoop_protocol.ColumnDefinition, class string) scoop_protocol.Operation {
+	op := scoop_protocol.NewAddOperation(col.OutboundName, col.InboundName,
+		col.Transformer, core.ColumnOptionsFromString(col.ColumnCreationOptions).String(), col.SupportingColumns)
+	if class == "" {
+		class = core.DefaultPIIClass(col.Transformer)
+	}
+	op.ActionMetadata["pii"] = class
+	return op
+}
+
+// validateCreatePII returns an error if the PII classes of a create request
+// are not valid for its columns.
+func validateCreatePII(req *core.ClientCreateSchemaRequest) error {
+	transformers := make(map[string]string, len(req.Columns))
+	for _, col := range req.Columns {
+		transformers[col.OutboundName] = col.Transformer
+	}
+	for name, class := range req.PII {
+		transformer, ok := transformers[name]
+		if !ok {
+			return fmt.Errorf("PII class given for column %s, which is not in the schema", name)
+		}
+		if err := core.ValidatePIIClass(class, transformer); err != nil {
+			return fmt.Errorf("PII class invalid for %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CloneConfig builds the config of a new event from the columns of `source`
+// selected by the clone request.
+func CloneConfig(source *AnnotatedSchema, req *core.ClientCloneSchemaRequest) (*scoop_protocol.Config, error) {
+	existing := make(map[string]bool, len(source.Columns))
+	for _, col := range source.Columns {
+		existing[col.OutboundName] = true
+	}
+	include := make(map[string]bool, len(req.Include))
+	for _, name := range req.Include {
+		if !existing[name] {
+			return nil, fmt.Errorf("included column %s does not exist in %s", name, source.EventName)
+		}
+		include[name] = true
+	}
+	exclude := make(map[string]bool, len(req.Exclude))
+	for _, name := range req.Exclude {
+		if !existing[name] {
+			return nil, fmt.Errorf("excluded column %s does not exist in %s", name, source.EventName)
+		}
+		exclude[name] = true
+	}
+
+	cfg := &scoop_protocol.Config{EventName: req.EventName, Columns: []scoop_protocol.ColumnDefinition{}}
+	for _, col := range source.Columns {
+		if (len(include) > 0 && !include[col.OutboundName]) || exclude[col.OutboundName] {
+			continue
+		}
+		cfg.Columns = append(cfg.Columns, col)
+	}
+	return cfg, nil
+}
+
 // schemaUpdateRequestToOps converts a schema update request into a list of operations
 func schemaUpdateRequestToOps(req *core.ClientUpdateSchemaRequest) []scoop_protocol.Operation {
-	ops := make([]scoop_protocol.Operation, 0, len(req.Additions)+len(req.Deletes)+len(req.Renames))
+	ops := make([]scoop_protocol.Operation, 0,
+		len(req.Additions)+len(req.Deletes)+len(req.Renames)+len(req.Retypes)+len(req.Deprecations)+len(req.Classifications))
 	for _, colName := range req.Deletes {
 		ops = append(ops, scoop_protocol.NewDeleteOperation(colName))
 	}
+	for _, retype := range req.Retypes {
+		ops = append(ops, NewRetypeOperation(retype.OutboundName, retype.Transformer, retype.Options.String()))
+	}
+	for _, deprecation := range req.Deprecations {
+		ops = append(ops, NewDeprecateOperation(deprecation.OutboundName, deprecation.Reason, deprecation.Sunset))
+	}
+	classified := make([]string, 0, len(req.Classifications))
+	for name := range req.Classifications {
+		classified = append(classified, name)
+	}
+	sort.Strings(classified)
+	for _, name := range classified {
+		ops = append(ops, NewClassifyOperation(name, req.Classifications[name]))
+	}
 	for _, col := range req.Additions {
-		ops = append(ops, scoop_protocol.NewAddOperation(col.OutboundName, col.InboundName,
-			col.Transformer, col.Length, col.SupportingColumns))
+		ops = append(ops, newAddOperation(scoop_protocol.ColumnDefinition{
+			InboundName:           col.InboundName,
+			OutboundName:          col.OutboundName,
+			Transformer:           col.Transformer,
+			ColumnCreationOptions: col.Options.String(),
+			SupportingColumns:     col.SupportingColumns,
+		}, col.PII))
 	}
 	for oldName, newName := range req.Renames {
 		ops = append(ops, scoop_protocol.NewRenameOperation(oldName, newName))
@@ -221,9 +406,11 @@ func preValidateUpdate(req *core.ClientUpdateSchemaRequest, schema *AnnotatedSch
 		return "Attempted to modify drop-requested/dropped schema"
 	}
 
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
 	columnDefs := make(map[string]*scoop_protocol.ColumnDefinition)
-	for _, col := range schema.Columns {
-		columnDefs[col.OutboundName] = &col
+	for i := range schema.Columns {
+		columnDefs[schema.Columns[i].OutboundName] = &schema.Columns[i]
 	}
 
 	// Validate schema "delete"s
@@ -239,9 +426,88 @@ func preValidateUpdate(req *core.ClientUpdateSchemaRequest, schema *AnnotatedSch
 		if columnName == timeColName {
 			return "Cannot delete time column."
 		}
+		if !req.ForceDelete {
+			deprecation, deprecated := schema.Deprecations[columnName]
+			if !deprecated {
+				return fmt.Sprintf("Column must be deprecated before it can be deleted: %s", columnName)
+			}
+			if !deprecation.SunsetPassed(now) {
+				return fmt.Sprintf("Column %s cannot be deleted before its sunset on %s", columnName, deprecation.Sunset)
+			}
+		}
 		delete(columnDefs, columnName)
 	}
 
+	// Validate schema "deprecation"s
+	deprecateSet := make(map[string]bool)
+	for _, deprecation := range req.Deprecations {
+		if _, exists := columnDefs[deprecation.OutboundName]; !exists {
+			return fmt.Sprintf("Attempting to deprecate column that doesn't exist: %s", deprecation.OutboundName)
+		}
+		if deprecateSet[deprecation.OutboundName] {
+			return fmt.Sprintf("Attempting to deprecate column more than once: %s", deprecation.OutboundName)
+		}
+		deprecateSet[deprecation.OutboundName] = true
+		if deprecation.OutboundName == timeColName {
+			return "Cannot deprecate time column."
+		}
+		if strings.TrimSpace(deprecation.Reason) == "" {
+			return fmt.Sprintf("Deprecation of %s must give a reason", deprecation.OutboundName)
+		}
+		sunset, err := time.Parse(core.SunsetFormat, deprecation.Sunset)
+		if err != nil {
+			return fmt.Sprintf("Sunset of %s must be a date like %s, given %q",
+				deprecation.OutboundName, core.SunsetFormat, deprecation.Sunset)
+		}
+		if sunset.Before(today) {
+			return fmt.Sprintf("Sunset of %s cannot be in the past: %s", deprecation.OutboundName, deprecation.Sunset)
+		}
+	}
+
+	// Validate schema "classification"s
+	for name, class := range req.Classifications {
+		existingCol, exists := columnDefs[name]
+		if !exists {
+			return fmt.Sprintf("Attempting to classify column that doesn't exist: %s", name)
+		}
+		if err := core.ValidatePIIClass(class, existingCol.Transformer); err != nil {
+			return fmt.Sprintf("PII class invalid for %s: %v", name, err)
+		}
+	}
+
+	// Validate schema "retype"s
+	retypeSet := make(map[string]bool)
+	for _, retype := range req.Retypes {
+		existingCol, exists := columnDefs[retype.OutboundName]
+		if !exists {
+			return fmt.Sprintf("Attempting to retype column that doesn't exist: %s", retype.OutboundName)
+		}
+		if retypeSet[retype.OutboundName] {
+			return fmt.Sprintf("Attempting to retype column more than once: %s", retype.OutboundName)
+		}
+		retypeSet[retype.OutboundName] = true
+		err := validateIsNotKey(existingCol.ColumnCreationOptions)
+		if err != nil {
+			return fmt.Sprintf("Column is a key and cannot be retyped: %s", retype.OutboundName)
+		}
+		err = validateType(retype.Transformer)
+		if err != nil {
+			return fmt.Sprintf("Column transformer invalid: %v", err)
+		}
+		err = retype.Options.Validate(retype.Transformer)
+		if err != nil {
+			return fmt.Sprintf("Column options invalid for %s: %v", retype.OutboundName, err)
+		}
+		existingOptions, err := core.ParseColumnOptions(existingCol.ColumnCreationOptions)
+		if err != nil {
+			return fmt.Sprintf("Cannot retype column %s: current options invalid: %v", retype.OutboundName, err)
+		}
+		err = validateRetype(existingCol.Transformer, existingOptions, retype.Transformer, retype.Options)
+		if err != nil {
+			return fmt.Sprintf("Cannot retype column %s: %v", retype.OutboundName, err)
+		}
+	}
+
 	// Validate schema "add"s
 	for _, col := range req.Additions {
 		err := validateOutboundName(col.OutboundName)
@@ -252,6 +518,18 @@ func preValidateUpdate(req *core.ClientUpdateSchemaRequest, schema *AnnotatedSch
 		if err != nil {
 			return fmt.Sprintf("Column transformer invalid: %v", err)
 		}
+		err = col.Options.Validate(col.Transformer)
+		if err != nil {
+			return fmt.Sprintf("Column options invalid for %s: %v", col.OutboundName, err)
+		}
+		if col.PII != "" {
+			if err = core.ValidatePIIClass(col.PII, col.Transformer); err != nil {
+				return fmt.Sprintf("PII class invalid for %s: %v", col.OutboundName, err)
+			}
+		}
+		if col.Options.DistKey || col.Options.SortKey {
+			return fmt.Sprintf("Keys can only be set when creating a schema, cannot add key column: %s", col.OutboundName)
+		}
 		_, exists := columnDefs[col.OutboundName]
 		if exists {
 			return fmt.Sprintf("Attempting to add duplicate column: %s", col.OutboundName)
@@ -0,0 +1,219 @@
+package bpdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// maxColumnDocExamples is the most example values a column doc may list.
+const maxColumnDocExamples = 10
+
+var (
+	allColumnDocsQuery = `
+SELECT DISTINCT ON (event, column_name) event, column_name, description, examples, owner, units, ts, user_name, version
+FROM column_doc
+ORDER BY event, column_name, version DESC`
+
+	eventColumnDocsQuery = `
+SELECT DISTINCT ON (event, column_name) event, column_name, description, examples, owner, units, ts, user_name, version
+FROM column_doc
+WHERE event = $1
+ORDER BY event, column_name, version DESC`
+
+	insertColumnDocQuery = `
+INSERT INTO column_doc (event, column_name, description, examples, owner, units, user_name, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7,
+        (SELECT COALESCE(MAX(version) + 1, 1) FROM column_doc WHERE event = $1 AND column_name = $2))`
+
+	// renameColumnDocQuery copies the latest doc of column $2 to column $3 as
+	// a new version, recording the user who renamed it.
+	renameColumnDocQuery = `
+INSERT INTO column_doc (event, column_name, description, examples, owner, units, user_name, version)
+SELECT event, $3, description, examples, owner, units, $4,
+       (SELECT COALESCE(MAX(version) + 1, 1) FROM column_doc WHERE event = $1 AND column_name = $3)
+FROM (SELECT * FROM column_doc
+      WHERE event = $1 AND column_name = $2
+      ORDER BY version DESC
+      LIMIT 1) latest
+WHERE description <> ''`
+
+	// retireColumnDocQuery stores an empty doc as the latest version of
+	// column $2 if it is documented, recording the user who deleted or
+	// renamed it.
+	retireColumnDocQuery = `
+INSERT INTO column_doc (event, column_name, description, examples, owner, units, user_name, version)
+SELECT event, column_name, '', '[]', '', '', $3, version + 1
+FROM (SELECT * FROM column_doc
+      WHERE event = $1 AND column_name = $2
+      ORDER BY version DESC
+      LIMIT 1) latest
+WHERE description <> ''`
+)
+
+// ColumnDoc documents what a column means. Each change is stored as a new
+// version, like event metadata. When a column is deleted or renamed, a doc
+// with an empty description is stored under its old name so that a column
+// added later with that name does not inherit the doc.
+type ColumnDoc struct {
+	Description string
+	Examples    []string
+	Owner       string
+	Units       string
+	TS          time.Time
+	UserName    string
+	Version     int
+}
+
+// AttachColumnDocs sets the docs of the schema to those of its columns.
+func AttachColumnDocs(schema *AnnotatedSchema, docs map[string]ColumnDoc) {
+	schema.ColumnDocs = nil
+	for _, col := range schema.Columns {
+		doc, ok := docs[col.OutboundName]
+		if !ok {
+			continue
+		}
+		if schema.ColumnDocs == nil {
+			schema.ColumnDocs = make(map[string]ColumnDoc)
+		}
+		schema.ColumnDocs[col.OutboundName] = doc
+	}
+}
+
+// validateColumnDoc returns an error if the doc update is not valid for the schema.
+func validateColumnDoc(req *core.ClientUpdateColumnDocRequest, schema *AnnotatedSchema) error {
+	found := false
+	for _, col := range schema.Columns {
+		found = found || col.OutboundName == req.Column
+	}
+	if !found {
+		return fmt.Errorf("column %s does not exist in %s", req.Column, req.EventName)
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		return errors.New("description is required")
+	}
+	if len(req.Examples) > maxColumnDocExamples {
+		return fmt.Errorf("at most %d examples are allowed, given %d", maxColumnDocExamples, len(req.Examples))
+	}
+	return nil
+}
+
+// UpdateColumnDoc stores a new version of the doc of a column.
+func (s *schemaBackend) UpdateColumnDoc(req *core.ClientUpdateColumnDocRequest, user string) *core.WebError {
+	schema, err := s.Schema(req.EventName, nil)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting schema to validate column doc update: %v", err)
+	}
+	if schema == nil {
+		return core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	if err = validateColumnDoc(req, schema); err != nil {
+		return core.NewUserWebError(err)
+	}
+	examples := req.Examples
+	if examples == nil {
+		examples = []string{}
+	}
+	b, err := json.Marshal(examples)
+	if err != nil {
+		return core.NewServerWebErrorf("marshalling column doc examples: %v", err)
+	}
+	_, err = s.db.Exec(insertColumnDocQuery, req.EventName, req.Column, req.Description, b, req.Owner, req.Units, user)
+	if err != nil {
+		return core.NewServerWebErrorf("INSERTing column_doc row on %s.%s: %v", req.EventName, req.Column, err)
+	}
+	return nil
+}
+
+// columnDocMove is the doc of column From moving to column To as the column
+// is renamed, or being retired if To is empty as the column is deleted.
+type columnDocMove struct {
+	From string
+	To   string
+}
+
+// columnDocMoves returns how the operations move column docs, in order.
+func columnDocMoves(ops []scoop_protocol.Operation) []columnDocMove {
+	var moves []columnDocMove
+	for _, op := range ops {
+		switch op.Action {
+		case scoop_protocol.RENAME:
+			moves = append(moves, columnDocMove{From: op.Name, To: op.ActionMetadata["new_outbound"]})
+		case scoop_protocol.DELETE:
+			moves = append(moves, columnDocMove{From: op.Name})
+		}
+	}
+	return moves
+}
+
+// moveColumnDoc carries the doc of a renamed column over to its new name and
+// retires the doc of its old name. Does not commit.
+func moveColumnDoc(tx *sql.Tx, eventName string, move columnDocMove, user string) error {
+	if move.To != "" {
+		if _, err := tx.Exec(renameColumnDocQuery, eventName, move.From, move.To, user); err != nil {
+			return fmt.Errorf("carrying over doc of %s.%s to %s: %v", eventName, move.From, move.To, err)
+		}
+	}
+	if _, err := tx.Exec(retireColumnDocQuery, eventName, move.From, user); err != nil {
+		return fmt.Errorf("retiring doc of %s.%s: %v", eventName, move.From, err)
+	}
+	return nil
+}
+
+func (s *schemaBackend) queryColumnDocs(query string, args ...interface{}) (map[string]map[string]ColumnDoc, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying for column docs: %v", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.WithError(err).Error("closing rows in postgres backend queryColumnDocs")
+		}
+	}()
+	docs := make(map[string]map[string]ColumnDoc)
+	for rows.Next() {
+		var doc ColumnDoc
+		var eventName, column string
+		var examples []byte
+		err := rows.Scan(&eventName, &column, &doc.Description, &examples, &doc.Owner, &doc.Units,
+			&doc.TS, &doc.UserName, &doc.Version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing column_doc row: %v", err)
+		}
+		if err = json.Unmarshal(examples, &doc.Examples); err != nil {
+			return nil, fmt.Errorf("unmarshalling examples of %s.%s: %v", eventName, column, err)
+		}
+		if doc.Description == "" {
+			// The column was deleted or renamed since it was documented.
+			continue
+		}
+		if _, exists := docs[eventName]; !exists {
+			docs[eventName] = make(map[string]ColumnDoc)
+		}
+		docs[eventName][column] = doc
+	}
+	return docs, rows.Err()
+}
+
+// ColumnDocs returns the current doc of each documented column of the event.
+func (s *schemaBackend) ColumnDocs(eventName string) (map[string]ColumnDoc, error) {
+	docs, err := s.queryColumnDocs(eventColumnDocsQuery, eventName)
+	if err != nil {
+		return nil, err
+	}
+	return docs[eventName], nil
+}
+
+// AllColumnDocs returns the current column docs of every event, by event
+// name and then column name.
+func (s *schemaBackend) AllColumnDocs() (map[string]map[string]ColumnDoc, error) {
+	return s.queryColumnDocs(allColumnDocsQuery)
+}
@@ -0,0 +1,75 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestAttachColumnDocs(t *testing.T) {
+	require := require.New(t)
+	schema := &AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			varcharColumn("quality", 16, ""),
+		},
+	}
+	AttachColumnDocs(schema, map[string]ColumnDoc{
+		"quality":      {Description: "Video quality the player chose", Examples: []string{"720p60"}},
+		"deleted_once": {Description: "Gone"},
+	})
+	require.Equal(map[string]ColumnDoc{
+		"quality": {Description: "Video quality the player chose", Examples: []string{"720p60"}},
+	}, schema.ColumnDocs)
+
+	AttachColumnDocs(schema, nil)
+	require.Nil(schema.ColumnDocs)
+}
+
+func TestValidateColumnDoc(t *testing.T) {
+	require := require.New(t)
+	schema := &AnnotatedSchema{
+		EventName: "test",
+		Columns:   []scoop_protocol.ColumnDefinition{varcharColumn("quality", 16, "")},
+	}
+	req := &core.ClientUpdateColumnDocRequest{EventName: "test", Column: "backend", Description: "Backend"}
+	require.EqualError(validateColumnDoc(req, schema), "column backend does not exist in test")
+
+	req.Column = "quality"
+	req.Description = " "
+	require.EqualError(validateColumnDoc(req, schema), "description is required")
+
+	req.Description = "Video quality the player chose"
+	req.Examples = make([]string, maxColumnDocExamples+1)
+	require.EqualError(validateColumnDoc(req, schema), "at most 10 examples are allowed, given 11")
+
+	req.Examples = []string{"720p60", "chunked"}
+	require.Nil(validateColumnDoc(req, schema))
+}
+
+func TestColumnDocMoves(t *testing.T) {
+	require := require.New(t)
+	require.Nil(columnDocMoves([]scoop_protocol.Operation{
+		scoop_protocol.NewAddOperation("quality", "quality", "varchar", "(16)", ""),
+	}))
+
+	// A column deleted and added back with the same name must not inherit the
+	// doc of the deleted column.
+	ops := []scoop_protocol.Operation{
+		scoop_protocol.NewDeleteOperation("quality"),
+		scoop_protocol.NewAddOperation("quality", "quality", "bigint", "", ""),
+	}
+	require.Equal([]columnDocMove{{From: "quality"}}, columnDocMoves(ops))
+
+	ops = []scoop_protocol.Operation{
+		scoop_protocol.NewRenameOperation("quality", "video_quality"),
+		scoop_protocol.NewRenameOperation("backend", "quality"),
+	}
+	require.Equal([]columnDocMove{
+		{From: "quality", To: "video_quality"},
+		{From: "backend", To: "quality"},
+	}, columnDocMoves(ops))
+}
@@ -0,0 +1,136 @@
+package bpdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// Kinds of datastore an event can be loaded into.
+const (
+	DatastoreRedshift  = "redshift"
+	DatastoreS3Archive = "s3_archive"
+	DatastoreKinesis   = "kinesis"
+)
+
+// kinesisDatastorePrefix starts the datastore naming a Kinesis stream, as in
+// "kinesis:<stream name>".
+const kinesisDatastorePrefix = DatastoreKinesis + ":"
+
+// Datastore is a datastore events can be loaded into.
+type Datastore struct {
+	Name        string
+	Kind        string
+	Description string
+}
+
+// Datastores is the registry of the datastores DATASTORES metadata can name.
+// The Kinesis entry stands for every Kinesis stream, each named as
+// "kinesis:<stream name>".
+var Datastores = []Datastore{
+	{Name: DatastoreRedshift, Kind: DatastoreRedshift, Description: "Redshift"},
+	{Name: "ace", Kind: DatastoreRedshift, Description: "The Ace Redshift cluster"},
+	{Name: "tahoe", Kind: DatastoreRedshift, Description: "The Tahoe Redshift cluster"},
+	{Name: DatastoreS3Archive, Kind: DatastoreS3Archive, Description: "The S3 archive of raw events"},
+	{Name: DatastoreKinesis, Kind: DatastoreKinesis, Description: "A Kinesis stream, named as kinesis:<stream name>"},
+}
+
+// datastoreTokens splits a DATASTORES value, which is either a comma separated
+// list or a JSON array, into trimmed names, ignoring empty ones.
+func datastoreTokens(value string) ([]string, error) {
+	var names []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &names); err != nil {
+			return nil, fmt.Errorf("parsing datastores %q: %v", value, err)
+		}
+	} else {
+		names = strings.Split(value, ",")
+	}
+	tokens := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			tokens = append(tokens, name)
+		}
+	}
+	return tokens, nil
+}
+
+// canonicalDatastore returns the registry name of the datastore, or
+// "kinesis:<stream name>" for a Kinesis stream. Names are case insensitive
+// except for stream names. Names that are not in the registry are errors
+// rather than guesses, so that a person can decide what they meant.
+func canonicalDatastore(name string) (string, error) {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, kinesisDatastorePrefix) {
+		stream := strings.TrimSpace(name[len(kinesisDatastorePrefix):])
+		if stream == "" {
+			return "", fmt.Errorf("datastore %q does not name a Kinesis stream", name)
+		}
+		return kinesisDatastorePrefix + stream, nil
+	}
+	for _, datastore := range Datastores {
+		if datastore.Name != lower {
+			continue
+		}
+		if datastore.Kind == DatastoreKinesis {
+			return "", fmt.Errorf("datastore %q does not name a Kinesis stream, use %s<stream name>", name, kinesisDatastorePrefix)
+		}
+		return lower, nil
+	}
+	return "", fmt.Errorf("unknown datastore %q", name)
+}
+
+// NormalizeDatastores parses a DATASTORES value and returns it as a sorted,
+// comma separated set of canonical datastore names.
+func NormalizeDatastores(value string) (string, error) {
+	tokens, err := datastoreTokens(value)
+	if err != nil {
+		return "", err
+	}
+	seen := make(map[string]bool, len(tokens))
+	names := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		name, err := canonicalDatastore(token)
+		if err != nil {
+			return "", err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ","), nil
+}
+
+// KinesisDatastoreStreams returns the names of the Kinesis streams in a
+// normalized DATASTORES value.
+func KinesisDatastoreStreams(normalized string) []string {
+	var streams []string
+	for _, name := range strings.Split(normalized, ",") {
+		if strings.HasPrefix(name, kinesisDatastorePrefix) {
+			streams = append(streams, name[len(kinesisDatastorePrefix):])
+		}
+	}
+	return streams
+}
+
+// ValidateDatastoreStreams returns an error if a Kinesis stream in the
+// normalized DATASTORES value has no current Kinesis config.
+func ValidateDatastoreStreams(normalized string, configs []scoop_protocol.AnnotatedKinesisConfig) error {
+	existing := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if !config.Dropped {
+			existing[config.SpadeConfig.StreamName] = true
+		}
+	}
+	for _, stream := range KinesisDatastoreStreams(normalized) {
+		if !existing[stream] {
+			return fmt.Errorf("Kinesis stream %s has no Kinesis config", stream)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestNormalizeDatastores(t *testing.T) {
+	require := require.New(t)
+	for value, expected := range map[string]string{
+		"":                                  "",
+		"redshift":                          "redshift",
+		"redshift,Redshift":                 "redshift",
+		`["redshift"]`:                      "redshift",
+		"Redshift ":                         "redshift",
+		"redshift,ace":                      "ace,redshift",
+		`["tahoe"]`:                         "tahoe",
+		"Tahoe, ACE":                        "ace,tahoe",
+		"s3_archive, REDSHIFT,,":            "redshift,s3_archive",
+		"Kinesis:Spade-Downstream,redshift": "kinesis:Spade-Downstream,redshift",
+		`["kinesis: stream", "S3_Archive"]`: "kinesis:stream,s3_archive",
+	} {
+		normalized, err := NormalizeDatastores(value)
+		require.Nil(err, value)
+		require.Equal(expected, normalized, value)
+	}
+	for _, value := range []string{"mysql", "kinesis", "kinesis:", `["redshift"`, "redshift,hive", "s3", "archive"} {
+		_, err := NormalizeDatastores(value)
+		require.NotNil(err, value)
+	}
+}
+
+func TestValidateDatastoreStreams(t *testing.T) {
+	require := require.New(t)
+	configs := []scoop_protocol.AnnotatedKinesisConfig{
+		{SpadeConfig: scoop_protocol.KinesisWriterConfig{StreamName: "live"}},
+		{SpadeConfig: scoop_protocol.KinesisWriterConfig{StreamName: "gone"}, Dropped: true},
+	}
+	require.Equal([]string{"gone", "live"}, KinesisDatastoreStreams("kinesis:gone,kinesis:live,redshift"))
+	require.Nil(ValidateDatastoreStreams("kinesis:live,redshift", configs))
+	require.NotNil(ValidateDatastoreStreams("kinesis:gone", configs))
+	require.NotNil(ValidateDatastoreStreams("kinesis:missing", configs))
+}
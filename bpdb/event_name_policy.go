@@ -0,0 +1,208 @@
+package bpdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IDs of the built in event name rules.
+const (
+	EventNameRequiredPrefix = "required_prefix"
+	EventNameBannedSuffix   = "banned_suffix"
+	EventNameMaxLength      = "max_length"
+	EventNameSimilar        = "similar_name"
+)
+
+// EventNamePolicyConfig configures the built in event name rules. Rules whose
+// config is empty are not checked.
+type EventNamePolicyConfig struct {
+	// RequiredPrefixes maps each team to the prefixes of its events. Names
+	// must start with one of the prefixes of the team that will own the event.
+	RequiredPrefixes map[string][]string `json:"requiredPrefixes"`
+
+	// BannedSuffixes are suffixes names must not end with, e.g. "_test".
+	BannedSuffixes []string `json:"bannedSuffixes"`
+
+	// MaxLength is the longest name allowed.
+	MaxLength int `json:"maxLength"`
+
+	// RejectSimilarNames rejects names within an edit distance of 1 of an
+	// existing event, treating hyphens and underscores as the same.
+	RejectSimilarNames bool `json:"rejectSimilarNames"`
+}
+
+// EventNameRule is a rule new event names must follow.
+type EventNameRule interface {
+	// Check returns a message for each way `name` breaks the rule, given the
+	// team that will own the event, if known, and the names of the existing
+	// events.
+	Check(name, team string, existing []string) []string
+}
+
+// EventNameRuleFunc adapts a function to an EventNameRule.
+type EventNameRuleFunc func(name, team string, existing []string) []string
+
+// Check calls f.
+func (f EventNameRuleFunc) Check(name, team string, existing []string) []string {
+	return f(name, team, existing)
+}
+
+// EventNameViolation is a way a name breaks a rule.
+type EventNameViolation struct {
+	Rule    string
+	Message string
+}
+
+// EventNamePolicy is the set of rules new event names must follow.
+type EventNamePolicy struct {
+	ids   []string
+	rules map[string]EventNameRule
+}
+
+// NewEventNamePolicy returns a policy with the built in rules that are
+// configured.
+func NewEventNamePolicy(conf EventNamePolicyConfig) (*EventNamePolicy, error) {
+	p := &EventNamePolicy{rules: make(map[string]EventNameRule)}
+	if len(conf.RequiredPrefixes) > 0 {
+		p.AddRule(EventNameRequiredPrefix, requiredPrefixRule(conf.RequiredPrefixes))
+	}
+	if len(conf.BannedSuffixes) > 0 {
+		p.AddRule(EventNameBannedSuffix, bannedSuffixRule(conf.BannedSuffixes))
+	}
+	if conf.MaxLength < 0 {
+		return nil, fmt.Errorf("max event name length must not be negative, given %d", conf.MaxLength)
+	}
+	if conf.MaxLength > 0 {
+		p.AddRule(EventNameMaxLength, maxLengthRule(conf.MaxLength))
+	}
+	if conf.RejectSimilarNames {
+		p.AddRule(EventNameSimilar, EventNameRuleFunc(similarNameRule))
+	}
+	return p, nil
+}
+
+// AddRule adds a rule to the policy, replacing any rule with the same ID.
+func (p *EventNamePolicy) AddRule(id string, rule EventNameRule) {
+	if _, ok := p.rules[id]; !ok {
+		p.ids = append(p.ids, id)
+	}
+	p.rules[id] = rule
+}
+
+// Empty returns whether the policy has no rules.
+func (p *EventNamePolicy) Empty() bool {
+	return len(p.ids) == 0
+}
+
+// Check returns every way the name of an event to be owned by `team` breaks
+// the policy's rules, in the order the rules were added.
+func (p *EventNamePolicy) Check(name, team string, existing []string) []EventNameViolation {
+	violations := []EventNameViolation{}
+	for _, id := range p.ids {
+		for _, message := range p.rules[id].Check(name, team, existing) {
+			violations = append(violations, EventNameViolation{Rule: id, Message: message})
+		}
+	}
+	return violations
+}
+
+// EventNameViolationsError returns an error describing the violations, or
+// nil if there are none.
+func EventNameViolationsError(name string, violations []EventNameViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, fmt.Sprintf("%s (%s)", v.Message, v.Rule))
+	}
+	return fmt.Errorf("event name %s breaks naming policy: %s", name, strings.Join(messages, "; "))
+}
+
+func requiredPrefixRule(teamPrefixes map[string][]string) EventNameRuleFunc {
+	teams := make([]string, 0, len(teamPrefixes))
+	for team := range teamPrefixes {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	return func(name, team string, existing []string) []string {
+		if team == "" {
+			return []string{fmt.Sprintf("needs an owning team to check its prefix, one of %s", strings.Join(teams, ", "))}
+		}
+		prefixes, ok := teamPrefixes[team]
+		if !ok {
+			return []string{fmt.Sprintf("is owned by team %s, which has no event prefixes", team)}
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		}
+		sorted := append([]string{}, prefixes...)
+		sort.Strings(sorted)
+		return []string{fmt.Sprintf("must start with a prefix of team %s, one of %s", team, strings.Join(sorted, ", "))}
+	}
+}
+
+func bannedSuffixRule(suffixes []string) EventNameRuleFunc {
+	return func(name, team string, existing []string) []string {
+		var messages []string
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(name, suffix) {
+				messages = append(messages, fmt.Sprintf("must not end with %s", suffix))
+			}
+		}
+		return messages
+	}
+}
+
+func maxLengthRule(max int) EventNameRuleFunc {
+	return func(name, team string, existing []string) []string {
+		if len(name) > max {
+			return []string{fmt.Sprintf("is %d characters long, the maximum is %d", len(name), max)}
+		}
+		return nil
+	}
+}
+
+// similarNameRule flags existing events whose name differs by one edit.
+// Names that only differ in hyphens and underscores are left to the check
+// for existing events.
+func similarNameRule(name, team string, existing []string) []string {
+	normalized := looseEventName(name)
+	var messages []string
+	for _, other := range existing {
+		otherNormalized := looseEventName(other)
+		if otherNormalized != normalized && withinOneEdit(normalized, otherNormalized) {
+			messages = append(messages, fmt.Sprintf("is too similar to existing event %s", other))
+		}
+	}
+	return messages
+}
+
+func looseEventName(name string) string {
+	return strings.Replace(strings.ToLower(name), "-", "_", -1)
+}
+
+// withinOneEdit returns whether a can be turned into b by inserting,
+// deleting or substituting at most one character.
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if i == len(a) {
+		return true
+	}
+	if len(a) == len(b) {
+		return a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
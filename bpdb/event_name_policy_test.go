@@ -0,0 +1,95 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinOneEdit(t *testing.T) {
+	require := require.New(t)
+	require.True(withinOneEdit("minute_watched", "minute_watched"))
+	require.True(withinOneEdit("minute_watched", "minute_watchd"))
+	require.True(withinOneEdit("minute_watched", "minute_watches"))
+	require.True(withinOneEdit("minute_watched", "minutes_watched"))
+	require.True(withinOneEdit("", "a"))
+	require.False(withinOneEdit("minute_watched", "minutes_watches"))
+	require.False(withinOneEdit("minute_watched", "minute_watch"))
+}
+
+func TestEventNamePolicy(t *testing.T) {
+	require := require.New(t)
+	policy, err := NewEventNamePolicy(EventNamePolicyConfig{
+		RequiredPrefixes:   map[string][]string{"video": {"video_"}, "chat": {"chat_", "whisper_"}},
+		BannedSuffixes:     []string{"_test", "_tmp"},
+		MaxLength:          20,
+		RejectSimilarNames: true,
+	})
+	require.Nil(err)
+	require.False(policy.Empty())
+
+	existing := []string{"video_play", "video-pause", "chat_message"}
+	require.Equal([]EventNameViolation{}, policy.Check("video_seek", "video", existing))
+	require.Equal([]EventNameViolation{}, policy.Check("video_play", "video", existing),
+		"existing events are left to the existence check")
+	require.Equal([]EventNameViolation{}, policy.Check("video_pause", "video", existing),
+		"hyphens and underscores are the same event")
+
+	violations := policy.Check("minute_watched_load_test", "chat", existing)
+	require.Equal([]EventNameViolation{
+		{Rule: EventNameRequiredPrefix, Message: "must start with a prefix of team chat, one of chat_, whisper_"},
+		{Rule: EventNameBannedSuffix, Message: "must not end with _test"},
+		{Rule: EventNameMaxLength, Message: "is 24 characters long, the maximum is 20"},
+	}, violations)
+	require.Equal("event name minute_watched_load_test breaks naming policy: "+
+		"must start with a prefix of team chat, one of chat_, whisper_ (required_prefix); "+
+		"must not end with _test (banned_suffix); "+
+		"is 24 characters long, the maximum is 20 (max_length)",
+		EventNameViolationsError("minute_watched_load_test", violations).Error())
+
+	require.Equal([]EventNameViolation{
+		{Rule: EventNameSimilar, Message: "is too similar to existing event video_play"},
+	}, policy.Check("video_plays", "video", existing))
+	require.Nil(EventNameViolationsError("video_seek", nil))
+}
+
+func TestEventNamePolicyTeamPrefixes(t *testing.T) {
+	require := require.New(t)
+	policy, err := NewEventNamePolicy(EventNamePolicyConfig{
+		RequiredPrefixes: map[string][]string{"video": {"video_"}, "chat": {"whisper_", "chat_"}},
+	})
+	require.Nil(err)
+
+	require.Equal([]EventNameViolation{}, policy.Check("video_seek", "video", nil))
+	require.Equal([]EventNameViolation{
+		{Rule: EventNameRequiredPrefix, Message: "must start with a prefix of team chat, one of chat_, whisper_"},
+	}, policy.Check("video_seek", "chat", nil), "another team's prefix is rejected")
+	require.Equal([]EventNameViolation{
+		{Rule: EventNameRequiredPrefix, Message: "is owned by team ads, which has no event prefixes"},
+	}, policy.Check("ads_click", "ads", nil))
+	require.Equal([]EventNameViolation{
+		{Rule: EventNameRequiredPrefix, Message: "needs an owning team to check its prefix, one of chat, video"},
+	}, policy.Check("video_seek", "", nil))
+}
+
+func TestEventNamePolicyCustomRule(t *testing.T) {
+	require := require.New(t)
+	policy, err := NewEventNamePolicy(EventNamePolicyConfig{})
+	require.Nil(err)
+	require.True(policy.Empty())
+	require.Equal([]EventNameViolation{}, policy.Check("anything", "", nil))
+
+	policy.AddRule("no_digits", EventNameRuleFunc(func(name, team string, existing []string) []string {
+		for _, r := range name {
+			if r >= '0' && r <= '9' {
+				return []string{"must not contain digits"}
+			}
+		}
+		return nil
+	}))
+	require.Equal([]EventNameViolation{{Rule: "no_digits", Message: "must not contain digits"}},
+		policy.Check("video_play2", "", nil))
+
+	_, err = NewEventNamePolicy(EventNamePolicyConfig{MaxLength: -1})
+	require.NotNil(err)
+}
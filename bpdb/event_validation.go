@@ -0,0 +1,204 @@
+package bpdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// Kinds of problem a property of a sample event can have.
+const (
+	PropertyUnknown      = "unknown"
+	PropertyTypeMismatch = "type_mismatch"
+	PropertyTooLong      = "too_long"
+	PropertyMissing      = "missing"
+)
+
+// timeProperty is the property every event must send.
+const timeProperty = "time"
+
+// PropertyProblem is a problem with one property of a sample event.
+type PropertyProblem struct {
+	Property string
+	Problem  string
+	Message  string
+}
+
+// EventValidation is the result of validating one sample event.
+type EventValidation struct {
+	Index    int
+	Valid    bool
+	Problems []PropertyProblem
+}
+
+// EventsValidation is the result of validating sample events against a schema.
+type EventsValidation struct {
+	EventName string
+	Version   int
+	Valid     bool
+	Events    []EventValidation
+}
+
+type problemsByProperty []PropertyProblem
+
+func (p problemsByProperty) Len() int           { return len(p) }
+func (p problemsByProperty) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p problemsByProperty) Less(i, j int) bool { return p[i].Property < p[j].Property }
+
+// checkIntegerValue returns an error unless the value is a JSON integer
+// between min and max.
+func checkIntegerValue(value interface{}, min, max int64) error {
+	n, ok := value.(json.Number)
+	if !ok {
+		return fmt.Errorf("expected an integer, given %s", jsonKind(value))
+	}
+	i, err := n.Int64()
+	if err != nil {
+		return fmt.Errorf("expected an integer, given %s", n)
+	}
+	if i < min || i > max {
+		return fmt.Errorf("%d is out of range", i)
+	}
+	return nil
+}
+
+// jsonKind describes the JSON type of a decoded value for error messages.
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case json.Number:
+		return "a number"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return "null"
+}
+
+// checkColumnValue returns the problem with the value of the property the
+// column reads, or nil if the column accepts it. Values must be decoded with
+// UseNumber so integers can be told apart from floats. Null is always accepted.
+func checkColumnValue(col scoop_protocol.ColumnDefinition, value interface{}) *PropertyProblem {
+	if value == nil {
+		return nil
+	}
+	mismatch := func(err error) *PropertyProblem {
+		return &PropertyProblem{
+			Property: col.InboundName,
+			Problem:  PropertyTypeMismatch,
+			Message:  fmt.Sprintf("column %s (%s): %v", col.OutboundName, col.Transformer, err),
+		}
+	}
+	switch col.Transformer {
+	case "int":
+		if err := checkIntegerValue(value, math.MinInt32, math.MaxInt32); err != nil {
+			return mismatch(err)
+		}
+	case "bigint", "userIDWithMapping":
+		if err := checkIntegerValue(value, math.MinInt64, math.MaxInt64); err != nil {
+			return mismatch(err)
+		}
+	case "float", "f@timestamp@unix", "f@timestamp@unix-utc":
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch(fmt.Errorf("expected a number, given %s", jsonKind(value)))
+		}
+		if _, err := n.Float64(); err != nil {
+			return mismatch(fmt.Errorf("expected a number, given %s", n))
+		}
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			return mismatch(fmt.Errorf("expected a boolean, given %s", jsonKind(value)))
+		}
+	case "ipAsn", "ipAsnInteger", "ipCity", "ipCountry", "ipRegion":
+		s, ok := value.(string)
+		if !ok {
+			return mismatch(fmt.Errorf("expected an IP address string, given %s", jsonKind(value)))
+		}
+		if net.ParseIP(s) == nil {
+			return mismatch(fmt.Errorf("%q is not an IP address", s))
+		}
+	case "varchar":
+		s, ok := value.(string)
+		if !ok {
+			return mismatch(fmt.Errorf("expected a string, given %s", jsonKind(value)))
+		}
+		opts, err := core.ParseColumnOptions(col.ColumnCreationOptions)
+		if err != nil {
+			return mismatch(fmt.Errorf("column has invalid options: %v", err))
+		}
+		length := opts.VarcharLength()
+		if len(s) > length {
+			return &PropertyProblem{
+				Property: col.InboundName,
+				Problem:  PropertyTooLong,
+				Message:  fmt.Sprintf("column %s is varchar(%d), given %d bytes", col.OutboundName, length, len(s)),
+			}
+		}
+	default:
+		return mismatch(fmt.Errorf("unknown transformer"))
+	}
+	return nil
+}
+
+// ValidateEvent checks the properties of a sample event against the schema,
+// reporting properties the schema does not read, values its columns cannot
+// store, and a missing time. Problems are sorted by property.
+func ValidateEvent(schema *AnnotatedSchema, properties map[string]interface{}) []PropertyProblem {
+	problems := []PropertyProblem{}
+	known := make(map[string]bool, len(schema.Columns))
+	for _, col := range schema.Columns {
+		known[col.InboundName] = true
+		value, ok := properties[col.InboundName]
+		if !ok {
+			continue
+		}
+		if problem := checkColumnValue(col, value); problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+	for name := range properties {
+		if !known[name] {
+			problems = append(problems, PropertyProblem{
+				Property: name,
+				Problem:  PropertyUnknown,
+				Message:  fmt.Sprintf("%s is not read by any column of %s", name, schema.EventName),
+			})
+		}
+	}
+	if properties[timeProperty] == nil {
+		problems = append(problems, PropertyProblem{
+			Property: timeProperty,
+			Problem:  PropertyMissing,
+			Message:  "every event must send time",
+		})
+	}
+	sort.Stable(problemsByProperty(problems))
+	return problems
+}
+
+// ValidateEvents checks each sample event against the schema.
+func ValidateEvents(schema *AnnotatedSchema, events []map[string]interface{}) *EventsValidation {
+	result := &EventsValidation{
+		EventName: schema.EventName,
+		Version:   schema.Version,
+		Valid:     true,
+		Events:    make([]EventValidation, 0, len(events)),
+	}
+	for i, properties := range events {
+		problems := ValidateEvent(schema, properties)
+		valid := len(problems) == 0
+		result.Valid = result.Valid && valid
+		result.Events = append(result.Events, EventValidation{Index: i, Valid: valid, Problems: problems})
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package bpdb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func decodeSampleEvent(t *testing.T, s string) map[string]interface{} {
+	var event map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	require.Nil(t, decoder.Decode(&event))
+	return event
+}
+
+func TestValidateEvents(t *testing.T) {
+	require := require.New(t)
+	schema := &AnnotatedSchema{
+		EventName: "minute-watched",
+		Version:   4,
+		Columns: []scoop_protocol.ColumnDefinition{
+			{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix"},
+			{InboundName: "minutes", OutboundName: "minutes", Transformer: "int"},
+			{InboundName: "channel", OutboundName: "channel", Transformer: "varchar", ColumnCreationOptions: "(5) sortkey"},
+			{InboundName: "live", OutboundName: "live", Transformer: "bool"},
+			{InboundName: "ip", OutboundName: "country", Transformer: "ipCountry"},
+		},
+	}
+	result := ValidateEvents(schema, []map[string]interface{}{
+		decodeSampleEvent(t, `{"time": 1500000000.5, "minutes": 1, "channel": "abc", "live": null, "ip": "10.0.0.1"}`),
+		decodeSampleEvent(t, `{"minutes": 1.5, "channel": "abcdef", "live": "yes", "ip": "nowhere", "chanel": "abc"}`),
+	})
+	require.False(result.Valid)
+	require.Equal(4, result.Version)
+	require.Len(result.Events, 2)
+	require.True(result.Events[0].Valid)
+	require.Empty(result.Events[0].Problems)
+
+	problems := result.Events[1].Problems
+	require.False(result.Events[1].Valid)
+	require.Len(problems, 6)
+	kinds := make(map[string]string, len(problems))
+	for _, p := range problems {
+		kinds[p.Property] = p.Problem
+	}
+	require.Equal(map[string]string{
+		"channel": PropertyTooLong,
+		"chanel":  PropertyUnknown,
+		"ip":      PropertyTypeMismatch,
+		"live":    PropertyTypeMismatch,
+		"minutes": PropertyTypeMismatch,
+		"time":    PropertyMissing,
+	}, kinds)
+	require.Equal("chanel", problems[0].Property)
+
+	result = ValidateEvents(schema, []map[string]interface{}{
+		decodeSampleEvent(t, `{"time": 1, "minutes": 3000000000}`),
+	})
+	require.False(result.Valid)
+	require.Equal(PropertyTypeMismatch, result.Events[0].Problems[0].Problem)
+}
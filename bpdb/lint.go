@@ -0,0 +1,246 @@
+package bpdb
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// Lint rules for column names.
+const (
+	LintReservedWord     = "reserved_word"
+	LintSnakeCase        = "snake_case"
+	LintIdentifierLength = "identifier_length"
+	LintNameConsistency  = "name_consistency"
+)
+
+// Severities of lint rules. Errors reject the change, warnings are reported
+// alongside it, and rules that are off are not checked.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+	LintOff     = "off"
+)
+
+// maxIdentifierLength is the longest identifier Redshift allows, in bytes.
+const maxIdentifierLength = 127
+
+var (
+	defaultLintSeverities = map[string]string{
+		LintReservedWord:     LintError,
+		LintSnakeCase:        LintWarning,
+		LintIdentifierLength: LintError,
+		LintNameConsistency:  LintWarning,
+	}
+
+	snakeCaseRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+	// redshiftReservedWords cannot be used as column names without quoting,
+	// which the ingester's DDL does not do.
+	redshiftReservedWords = makeWordSet(`
+aes128 aes256 all allowoverwrite analyse analyze and any array as asc
+authorization az64 backup between binary blanksasnull both bytedict bzip2
+case cast check collate column constraint create credentials cross
+current_date current_time current_timestamp current_user current_user_id
+default deferrable deflate defrag delta delta32k desc disable distinct do
+else emptyasnull enable encode encrypt encryption end except explicit false
+for foreign freeze from full globaldict256 globaldict64k grant group gzip
+having identity ignore ilike in initially inner intersect interval into is
+isnull join language leading left like limit localtime localtimestamp lun
+luns lzo lzop minus mostly16 mostly32 mostly8 natural new not notnull null
+nulls off offline offset oid old on only open or order outer overlaps
+parallel partition percent permissions pivot placing primary raw readratio
+recover references rejectlog resort respect restore right select
+session_user similar snapshot some sysdate system table tag tdes text255
+text32k then timestamp to top trailing true truncatecolumns union unique
+unnest unpivot user using verbose wallet when where with without`)
+)
+
+func makeWordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// LintConfig configures the lint rules.
+type LintConfig struct {
+	// Severities overrides the default severity of rules by name.
+	Severities map[string]string `json:"severities"`
+
+	// MaxIdentifierLength is the longest column name allowed, defaulting to
+	// Redshift's limit.
+	MaxIdentifierLength int `json:"maxIdentifierLength"`
+}
+
+// LintResult is a rule a column breaks.
+type LintResult struct {
+	Rule     string
+	Severity string
+	Column   string
+	Message  string
+}
+
+// LintReport is the result of linting the columns of a schema or a change to it.
+type LintReport struct {
+	EventName string
+	Results   []LintResult
+}
+
+type lintResultsByColumn []LintResult
+
+func (l lintResultsByColumn) Len() int      { return len(l) }
+func (l lintResultsByColumn) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l lintResultsByColumn) Less(i, j int) bool {
+	if l[i].Column != l[j].Column {
+		return l[i].Column < l[j].Column
+	}
+	return l[i].Rule < l[j].Rule
+}
+
+// Linter checks column names against the configured rules.
+type Linter struct {
+	severities          map[string]string
+	maxIdentifierLength int
+}
+
+// NewLinter returns a linter for the given config, or an error if it names
+// unknown rules or severities.
+func NewLinter(conf LintConfig) (*Linter, error) {
+	l := &Linter{
+		severities:          make(map[string]string, len(defaultLintSeverities)),
+		maxIdentifierLength: maxIdentifierLength,
+	}
+	for rule, severity := range defaultLintSeverities {
+		l.severities[rule] = severity
+	}
+	for rule, severity := range conf.Severities {
+		if _, ok := defaultLintSeverities[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %s", rule)
+		}
+		if severity != LintError && severity != LintWarning && severity != LintOff {
+			return nil, fmt.Errorf("lint rule %s has unknown severity %s", rule, severity)
+		}
+		l.severities[rule] = severity
+	}
+	if conf.MaxIdentifierLength < 0 || conf.MaxIdentifierLength > maxIdentifierLength {
+		return nil, fmt.Errorf("max identifier length must be between 1 and %d, given %d",
+			maxIdentifierLength, conf.MaxIdentifierLength)
+	}
+	if conf.MaxIdentifierLength > 0 {
+		l.maxIdentifierLength = conf.MaxIdentifierLength
+	}
+	return l, nil
+}
+
+// snakeCase converts a property name like videoQuality or Video-Quality to
+// snake case like video_quality.
+func snakeCase(name string) string {
+	var b bytes.Buffer
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// readsWholeProperty returns whether the column stores the property it reads
+// as is, rather than something derived from it like the city of an IP.
+func readsWholeProperty(transformer string) bool {
+	return !strings.HasPrefix(transformer, "ip")
+}
+
+func (l *Linter) add(results []LintResult, rule, column, format string, a ...interface{}) []LintResult {
+	severity := l.severities[rule]
+	if severity == LintOff {
+		return results
+	}
+	return append(results, LintResult{
+		Rule:     rule,
+		Severity: severity,
+		Column:   column,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// lintColumn checks the outbound name of a column, and whether it matches its
+// inbound name if that is given.
+func (l *Linter) lintColumn(results []LintResult, col scoop_protocol.ColumnDefinition) []LintResult {
+	name := col.OutboundName
+	if redshiftReservedWords[strings.ToLower(name)] {
+		results = l.add(results, LintReservedWord, name, "%s is a Redshift reserved word", name)
+	}
+	if !snakeCaseRe.MatchString(name) {
+		results = l.add(results, LintSnakeCase, name, "%s is not snake_case, consider %s", name, snakeCase(name))
+	}
+	if len(name) > l.maxIdentifierLength {
+		results = l.add(results, LintIdentifierLength, name, "%s is %d bytes long, the maximum is %d",
+			name, len(name), l.maxIdentifierLength)
+	}
+	if col.InboundName != "" && readsWholeProperty(col.Transformer) &&
+		name != col.InboundName && name != snakeCase(col.InboundName) {
+		results = l.add(results, LintNameConsistency, name, "%s does not match its inbound name %s",
+			name, col.InboundName)
+	}
+	return results
+}
+
+// LintColumns checks the columns of a new schema.
+func (l *Linter) LintColumns(cols []scoop_protocol.ColumnDefinition) []LintResult {
+	results := []LintResult{}
+	for _, col := range cols {
+		results = l.lintColumn(results, col)
+	}
+	sort.Sort(lintResultsByColumn(results))
+	return results
+}
+
+// LintUpdate checks the columns added and the new names of the columns
+// renamed by an update. Existing columns are not checked, so that schemas
+// that predate a rule can still be changed.
+func (l *Linter) LintUpdate(req *core.ClientUpdateSchemaRequest) []LintResult {
+	cols := make([]scoop_protocol.ColumnDefinition, 0, len(req.Additions)+len(req.Renames))
+	for _, col := range req.Additions {
+		cols = append(cols, scoop_protocol.ColumnDefinition{
+			InboundName:  col.InboundName,
+			OutboundName: col.OutboundName,
+			Transformer:  col.Transformer,
+		})
+	}
+	for _, newName := range req.Renames {
+		cols = append(cols, scoop_protocol.ColumnDefinition{OutboundName: newName})
+	}
+	return l.LintColumns(cols)
+}
+
+// LintErrors returns an error describing the results with error severity, or
+// nil if there are none.
+func LintErrors(results []LintResult) error {
+	var messages []string
+	for _, result := range results {
+		if result.Severity == LintError {
+			messages = append(messages, fmt.Sprintf("%s (%s)", result.Message, result.Rule))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("column names break lint rules: %s", strings.Join(messages, "; "))
+}
@@ -0,0 +1,54 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestLintColumns(t *testing.T) {
+	require := require.New(t)
+	linter, err := NewLinter(LintConfig{MaxIdentifierLength: 14})
+	require.Nil(err)
+	results := linter.LintColumns([]scoop_protocol.ColumnDefinition{
+		{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix"},
+		{InboundName: "order", OutboundName: "order", Transformer: "bigint"},
+		{InboundName: "videoQuality", OutboundName: "video_quality", Transformer: "varchar"},
+		{InboundName: "ip", OutboundName: "country", Transformer: "ipCountry"},
+		{InboundName: "channel", OutboundName: "ChannelName", Transformer: "varchar"},
+		{InboundName: "a_very_long_name", OutboundName: "a_very_long_name", Transformer: "varchar"},
+	})
+	require.Equal([]LintResult{
+		{Rule: LintNameConsistency, Severity: LintWarning, Column: "ChannelName", Message: "ChannelName does not match its inbound name channel"},
+		{Rule: LintSnakeCase, Severity: LintWarning, Column: "ChannelName", Message: "ChannelName is not snake_case, consider channel_name"},
+		{Rule: LintIdentifierLength, Severity: LintError, Column: "a_very_long_name", Message: "a_very_long_name is 16 bytes long, the maximum is 14"},
+		{Rule: LintReservedWord, Severity: LintError, Column: "order", Message: "order is a Redshift reserved word"},
+	}, results)
+	require.Equal("column names break lint rules: a_very_long_name is 16 bytes long, the maximum is 14 (identifier_length); "+
+		"order is a Redshift reserved word (reserved_word)", LintErrors(results).Error())
+}
+
+func TestLintConfig(t *testing.T) {
+	require := require.New(t)
+	_, err := NewLinter(LintConfig{Severities: map[string]string{"shouting": LintError}})
+	require.NotNil(err)
+	_, err = NewLinter(LintConfig{Severities: map[string]string{LintSnakeCase: "fatal"}})
+	require.NotNil(err)
+
+	linter, err := NewLinter(LintConfig{Severities: map[string]string{
+		LintReservedWord: LintWarning,
+		LintSnakeCase:    LintOff,
+	}})
+	require.Nil(err)
+	results := linter.LintUpdate(&core.ClientUpdateSchemaRequest{
+		Additions: []core.Column{{InboundName: "group", OutboundName: "group", Transformer: "varchar"}},
+		Renames:   core.Renames{"a": "Select"},
+	})
+	require.Len(results, 2)
+	require.Equal(LintWarning, results[0].Severity)
+	require.Equal("Select", results[0].Column)
+	require.Equal("group", results[1].Column)
+	require.Nil(LintErrors(results))
+}
@@ -0,0 +1,46 @@
+package bpdb
+
+import "sort"
+
+// SensitiveColumn is a column classified as holding personal data.
+type SensitiveColumn struct {
+	EventName    string
+	OutboundName string
+	InboundName  string
+	Transformer  string
+	PII          string
+}
+
+// SensitiveColumns returns the sensitive columns of the schemas, sorted by
+// event and then column name.
+func SensitiveColumns(schemas []AnnotatedSchema) []SensitiveColumn {
+	columns := []SensitiveColumn{}
+	for _, schema := range schemas {
+		for _, col := range schema.Columns {
+			class, ok := schema.PII[col.OutboundName]
+			if !ok {
+				continue
+			}
+			columns = append(columns, SensitiveColumn{
+				EventName:    schema.EventName,
+				OutboundName: col.OutboundName,
+				InboundName:  col.InboundName,
+				Transformer:  col.Transformer,
+				PII:          class,
+			})
+		}
+	}
+	sort.Sort(sensitiveColumnsByName(columns))
+	return columns
+}
+
+type sensitiveColumnsByName []SensitiveColumn
+
+func (s sensitiveColumnsByName) Len() int      { return len(s) }
+func (s sensitiveColumnsByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sensitiveColumnsByName) Less(i, j int) bool {
+	if s[i].EventName != s[j].EventName {
+		return s[i].EventName < s[j].EventName
+	}
+	return s[i].OutboundName < s[j].OutboundName
+}
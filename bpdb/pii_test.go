@@ -0,0 +1,37 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestSensitiveColumns(t *testing.T) {
+	require := require.New(t)
+	require.Equal([]SensitiveColumn{}, SensitiveColumns(nil))
+	schemas := []AnnotatedSchema{
+		{
+			EventName: "video_play",
+			Columns: []scoop_protocol.ColumnDefinition{
+				column("user_id", "userIDWithMapping", "", ""),
+				column("quality", "varchar", "(16)", ""),
+			},
+			PII: map[string]string{"user_id": core.PIIPseudonymous},
+		},
+		{
+			EventName: "chat_message",
+			Columns: []scoop_protocol.ColumnDefinition{
+				column("user_id", "userIDWithMapping", "", ""),
+				column("email", "varchar", "(64)", ""),
+			},
+			PII: map[string]string{"user_id": core.PIIPseudonymous, "email": core.PIIDirectIdentifier},
+		},
+	}
+	require.Equal([]SensitiveColumn{
+		{EventName: "chat_message", OutboundName: "email", InboundName: "email", Transformer: "varchar", PII: core.PIIDirectIdentifier},
+		{EventName: "chat_message", OutboundName: "user_id", InboundName: "user_id", Transformer: "userIDWithMapping", PII: core.PIIPseudonymous},
+		{EventName: "video_play", OutboundName: "user_id", InboundName: "user_id", Transformer: "userIDWithMapping", PII: core.PIIPseudonymous},
+	}, SensitiveColumns(schemas))
+}
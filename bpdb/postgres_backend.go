@@ -19,6 +19,15 @@ var (
 WHERE (schema, ts) IN (SELECT schema, MAX(ts) FROM schema_maintenance GROUP BY schema)`
 	setSchemaMaintenanceModeQuery = `INSERT INTO schema_maintenance (schema, is_maintenance, "user", reason) VALUES ($1, $2, $3, $4)`
 
+	blacklistPatternsQuery = `SELECT version, pattern, blacklisted, "user", reason, ts FROM event_blacklist
+WHERE version IN (SELECT MAX(version) FROM event_blacklist GROUP BY pattern) AND blacklisted
+ORDER BY pattern`
+	blacklistHistoryQuery = `SELECT version, pattern, blacklisted, "user", reason, ts FROM event_blacklist
+ORDER BY version DESC`
+	setBlacklistPatternQuery  = `INSERT INTO event_blacklist (pattern, blacklisted, "user", reason) VALUES ($1, $2, $3, $4)`
+	lockBlacklistQuery        = `LOCK TABLE event_blacklist IN EXCLUSIVE MODE`
+	blacklistChangeCountQuery = `SELECT COUNT(*) FROM event_blacklist`
+
 	dailyChangesLast30Days = `
 WITH changes AS (
     SELECT event, version, user_name, MIN(ts) AS ts FROM operation GROUP BY event, version, user_name
@@ -148,6 +157,72 @@ func (p *postgresBackend) SetMaintenanceMode(switchingOn bool, user, reason stri
 	return nil
 }
 
+func (p *postgresBackend) queryBlacklist(query string) ([]BlacklistChange, error) {
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("querying blacklist: %v", err)
+	}
+	defer func() {
+		defererr := rows.Close()
+		if defererr != nil {
+			logger.WithError(defererr).Error("closing rows from blacklist")
+		}
+	}()
+	changes := []BlacklistChange{}
+	for rows.Next() {
+		var change BlacklistChange
+		err = rows.Scan(&change.Version, &change.Pattern, &change.Blacklisted, &change.User, &change.Reason, &change.TS)
+		if err != nil {
+			return nil, fmt.Errorf("scanning blacklist row: %v", err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// BlacklistPatterns returns the patterns currently in the blacklist, with the
+// change that added each of them.
+func (p *postgresBackend) BlacklistPatterns() ([]BlacklistChange, error) {
+	return p.queryBlacklist(blacklistPatternsQuery)
+}
+
+// BlacklistHistory returns every change to the blacklist, newest first.
+func (p *postgresBackend) BlacklistHistory() ([]BlacklistChange, error) {
+	return p.queryBlacklist(blacklistHistoryQuery)
+}
+
+// SetBlacklistPattern adds the pattern to the blacklist or removes it.
+func (p *postgresBackend) SetBlacklistPattern(pattern string, blacklisted bool, user, reason string) error {
+	if _, err := p.db.Exec(setBlacklistPatternQuery, pattern, blacklisted, user, reason); err != nil {
+		return fmt.Errorf("storing blacklist pattern %s in db: %v", pattern, err)
+	}
+	return nil
+}
+
+// ImportBlacklistPatterns adds the patterns to the blacklist in one
+// transaction, unless the blacklist has already been changed, so that either
+// all of them are imported or none are.
+func (p *postgresBackend) ImportBlacklistPatterns(patterns []string, user, reason string) error {
+	return execFnInTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(lockBlacklistQuery); err != nil {
+			return fmt.Errorf("locking blacklist: %v", err)
+		}
+		var changes int
+		if err := tx.QueryRow(blacklistChangeCountQuery).Scan(&changes); err != nil {
+			return fmt.Errorf("counting blacklist changes: %v", err)
+		}
+		if changes > 0 {
+			return nil
+		}
+		for _, pattern := range patterns {
+			if _, err := tx.Exec(setBlacklistPatternQuery, pattern, true, user, reason); err != nil {
+				return fmt.Errorf("storing blacklist pattern %s in db: %v", pattern, err)
+			}
+		}
+		return nil
+	}, p.db)
+}
+
 // ActiveUsersLiast30Days lists active users with number of changes made for the last 30 days
 func (p *postgresBackend) ActiveUsersLast30Days() ([]*ActiveUser, error) {
 	rows, err := p.db.Query(activeUsersLast30Days)
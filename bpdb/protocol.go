@@ -0,0 +1,91 @@
+package bpdb
+
+import (
+	"strings"
+
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// RETYPE changes the type of an existing column in place. scoop_protocol only
+// defines the actions and metadata types the ingester has always understood,
+// so those added by blueprint are defined here.
+const RETYPE scoop_protocol.Action = "retype"
+
+// NewRetypeOperation returns an operation that changes the type of the column
+// `outbound` to `columnType` with the given creation options.
+func NewRetypeOperation(outbound, columnType, columnOptions string) scoop_protocol.Operation {
+	return scoop_protocol.Operation{
+		Action: RETYPE,
+		Name:   outbound,
+		ActionMetadata: map[string]string{
+			"column_type":    columnType,
+			"column_options": columnOptions,
+		},
+	}
+}
+
+// DEPRECATE marks a column as due to be deleted from its sunset date on.
+const DEPRECATE scoop_protocol.Action = "deprecate"
+
+// NewDeprecateOperation returns an operation that deprecates the column
+// `outbound` for the given reason until `sunset`, in core.SunsetFormat.
+func NewDeprecateOperation(outbound, reason, sunset string) scoop_protocol.Operation {
+	return scoop_protocol.Operation{
+		Action: DEPRECATE,
+		Name:   outbound,
+		ActionMetadata: map[string]string{
+			"reason": reason,
+			"sunset": sunset,
+		},
+	}
+}
+
+// CLASSIFY sets the PII class of a column.
+const CLASSIFY scoop_protocol.Action = "classify"
+
+// NewClassifyOperation returns an operation that sets the PII class of the
+// column `outbound` to `class`.
+func NewClassifyOperation(outbound, class string) scoop_protocol.Operation {
+	return scoop_protocol.Operation{
+		Action:         CLASSIFY,
+		Name:           outbound,
+		ActionMetadata: map[string]string{"pii": class},
+	}
+}
+
+// isMetadataAction returns whether the action only records metadata about a
+// column rather than changing the table. Such operations are stored as
+// versions like any other, but are kept out of migrations.
+func isMetadataAction(action scoop_protocol.Action) bool {
+	return action == DEPRECATE || action == CLASSIFY
+}
+
+// REQUIRED_PROPERTIES is the event metadata listing the inbound properties
+// producers must send with the event, separated by commas.
+const REQUIRED_PROPERTIES scoop_protocol.EventMetadataType = "required_properties"
+
+// ParseRequiredProperties splits the value of REQUIRED_PROPERTIES metadata
+// into property names, ignoring surrounding whitespace and empty entries.
+func ParseRequiredProperties(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Event metadata recording who owns an event and how its data is kept.
+const (
+	// OWNER_TEAM is the team that owns the event.
+	OWNER_TEAM scoop_protocol.EventMetadataType = "owner_team"
+	// ONCALL_CONTACT is who to contact when the event breaks: an email
+	// address, a Slack handle or a Slack channel.
+	ONCALL_CONTACT scoop_protocol.EventMetadataType = "oncall_contact"
+	// RETENTION_DAYS is how many days the event's data is kept.
+	RETENTION_DAYS scoop_protocol.EventMetadataType = "retention_days"
+	// SLA_TIER is the freshness SLA of the event's data, 1 being the strictest.
+	SLA_TIER scoop_protocol.EventMetadataType = "sla_tier"
+)
@@ -0,0 +1,17 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestIsMetadataAction(t *testing.T) {
+	require := require.New(t)
+	require.True(isMetadataAction(DEPRECATE))
+	require.True(isMetadataAction(CLASSIFY))
+	for _, action := range []scoop_protocol.Action{scoop_protocol.ADD, scoop_protocol.DELETE, scoop_protocol.RENAME, RETYPE} {
+		require.False(isMetadataAction(action), action)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"encoding/json"
 
-	_ "github.com/lib/pq" // to include the 'postgres' driver
+	"github.com/lib/pq"
 	"github.com/twitchscience/aws_utils/logger"
 	"github.com/twitchscience/blueprint/core"
 	"github.com/twitchscience/scoop_protocol/scoop_protocol"
@@ -51,6 +54,7 @@ ORDER BY version ASC, ordering ASC
 	insertOperationsQuery = `INSERT INTO operation
 (event, action, name, version, ordering, action_metadata, user_name)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ts
 `
 
 	nextVersionQuery = `SELECT max(version) + 1
@@ -73,6 +77,12 @@ GROUP BY event`
 		INSERT INTO event_metadata (event, metadata_type, metadata_value, user_name, version)
 		VALUES ($1, $2, $3, $4, $5);`
 
+	eventMetadataHistoryQuery = `
+		SELECT metadata_type, metadata_value, ts, user_name, version
+		  FROM event_metadata
+		 WHERE event = $1
+		 ORDER BY metadata_type, version DESC;`
+
 	nextEventMetadataVersionQuery = `
 		SELECT COALESCE(MAX(version) + 1, 1)
 		  FROM event_metadata
@@ -102,7 +112,9 @@ func NewSchemaBackend(db *sql.DB) (BpSchemaBackend, error) {
 	return s, nil
 }
 
-// Migration returns the operations necessary to migration `table` from version `to -1` to version `to`
+// Migration returns the operations necessary to migration `table` from version `to -1` to version `to`.
+// Metadata operations, such as deprecations, do not change the table and are left out, so the
+// migration to a version that only changed column metadata is empty.
 func (s *schemaBackend) Migration(table string, from int, to int) ([]*scoop_protocol.Operation, error) {
 	rows, err := s.db.Query(migrationQuery, from, to, table)
 	if err != nil {
@@ -125,6 +137,9 @@ func (s *schemaBackend) Migration(table string, from int, to int) ([]*scoop_prot
 		}
 
 		op.Action = scoop_protocol.Action(s)
+		if isMetadataAction(op.Action) {
+			continue
+		}
 		err = json.Unmarshal(b, &op.ActionMetadata)
 		if err != nil {
 			return nil, fmt.Errorf("unmarshalling action_metadata: %v", err)
@@ -134,15 +149,23 @@ func (s *schemaBackend) Migration(table string, from int, to int) ([]*scoop_prot
 	return ops, nil
 }
 
-// returns error but does not rollback on error. Does not commit.
+// errVersionTaken is returned by insertOperations when another update has
+// already been stored at the version being inserted.
+var errVersionTaken = errors.New("schema version already taken by a concurrent update")
+
+// insertOperations stores the operations as a new version of the event,
+// carries the docs of renamed columns over to their new names, retires the
+// docs of deleted columns and snapshots the resulting schema. Returns error but does not rollback on error. Does not
+// commit.
 func insertOperations(tx *sql.Tx, ops []scoop_protocol.Operation, version int, eventName, user string) error {
+	var ts time.Time
 	for i, op := range ops {
 		var b []byte
 		b, err := json.Marshal(op.ActionMetadata)
 		if err != nil {
 			return fmt.Errorf("marshalling %s column metadata json: %v", op.Action, err)
 		}
-		_, err = tx.Exec(insertOperationsQuery,
+		err = tx.QueryRow(insertOperationsQuery,
 			eventName,
 			string(op.Action),
 			op.Name,
@@ -150,38 +173,92 @@ func insertOperations(tx *sql.Tx, ops []scoop_protocol.Operation, version int, e
 			i, // ordering
 			b, // action_metadata
 			user,
-		)
+		).Scan(&ts)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return errVersionTaken
+		}
 		if err != nil {
-			rollErr := tx.Rollback()
-			if rollErr != nil {
-				return fmt.Errorf("rolling back commit: %v", rollErr)
-			}
 			return fmt.Errorf("INSERTing operation row on %s: %v", eventName, err)
 		}
 	}
-	return nil
+	for _, move := range columnDocMoves(ops) {
+		if err := moveColumnDoc(tx, eventName, move, user); err != nil {
+			return err
+		}
+	}
+	schema, err := nextSnapshot(tx, eventName, ops, version, ts, user)
+	if err != nil {
+		return err
+	}
+	return insertSnapshot(tx, schema)
+}
+
+// insertUpdate stores the operations of an update validated against
+// `baseVersion` as the next version, returning that version. Returns
+// errVersionTaken if the schema has moved on from `baseVersion`. Does not
+// commit.
+func insertUpdate(tx *sql.Tx, ops []scoop_protocol.Operation, baseVersion int, eventName, user string) (int, error) {
+	row := tx.QueryRow(nextVersionQuery, eventName)
+	var newVersion int
+	err := row.Scan(&newVersion)
+	if err != nil {
+		return 0, fmt.Errorf("parsing response for version number for %s: %v", eventName, err)
+	}
+	if newVersion != baseVersion+1 {
+		return 0, errVersionTaken
+	}
+	return newVersion, insertOperations(tx, ops, newVersion, eventName, user)
 }
 
 // CreateSchema validates that the creation operation is valid and if so, stores
 // the schema as 'add' operations in bpdb
-func (s *schemaBackend) CreateSchema(req *scoop_protocol.Config, user string) *core.WebError {
-	exists, err := s.looseSchemaExists(req.EventName)
+func (s *schemaBackend) CreateSchema(req *core.ClientCreateSchemaRequest, user string) *core.WebError {
+	ops, webErr := s.prepareCreate(req)
+	if webErr != nil {
+		return webErr
+	}
+	return s.insertNewSchema(&req.Config, req.OwnerTeam, ops, user)
+}
+
+// CloneSchema creates a new event from the columns of an existing one. The add
+// operations of the new event record the event and version it was cloned from,
+// and the cloned columns keep their PII classes.
+func (s *schemaBackend) CloneSchema(req *core.ClientCloneSchemaRequest, user string) *core.WebError {
+	source, err := s.Schema(req.SourceEventName, nil)
 	if err != nil {
-		return core.NewServerWebErrorf("checking for schema existence: %v", err)
+		return core.NewServerWebErrorf("error getting schema to clone: %v", err)
 	}
-	if exists {
-		return core.NewUserWebErrorf("Table already exists (check underscores/hyphens)")
+	if source == nil {
+		return core.NewUserWebError(errors.New("schema to clone does not exist"))
 	}
-	err = preValidateSchema(req)
+	cfg, err := CloneConfig(source, req)
 	if err != nil {
 		return core.NewUserWebError(err)
 	}
+	pii := make(map[string]string)
+	for _, col := range cfg.Columns {
+		if class, ok := source.PII[col.OutboundName]; ok {
+			pii[col.OutboundName] = class
+		}
+	}
+	ops, webErr := s.prepareCreate(&core.ClientCreateSchemaRequest{Config: *cfg, PII: pii})
+	if webErr != nil {
+		return webErr
+	}
+	for _, op := range ops {
+		op.ActionMetadata["cloned_from"] = source.EventName
+		op.ActionMetadata["cloned_from_version"] = strconv.Itoa(source.Version)
+	}
+	return s.insertNewSchema(cfg, req.OwnerTeam, ops, user)
+}
 
-	ops := schemaCreateRequestToOps(req)
-	return core.NewServerWebError(execFnInTransaction(func(tx *sql.Tx) error {
+// insertNewSchema stores the operations creating a new event, along with its
+// birth metadata and, if given, its owner team.
+func (s *schemaBackend) insertNewSchema(req *scoop_protocol.Config, ownerTeam string, ops []scoop_protocol.Operation, user string) *core.WebError {
+	err := execFnInTransaction(func(tx *sql.Tx) error {
 		row := tx.QueryRow(nextVersionQuery, req.EventName)
 		var newVersion int
-		err = row.Scan(&newVersion)
+		err := row.Scan(&newVersion)
 		switch {
 		case err == sql.ErrNoRows:
 			newVersion = 0
@@ -189,6 +266,9 @@ func (s *schemaBackend) CreateSchema(req *scoop_protocol.Config, user string) *c
 			return fmt.Errorf("parsing response for version number for %s: %v", req.EventName, err)
 		}
 		err = insertOperations(tx, ops, newVersion, req.EventName, user)
+		if err == errVersionTaken {
+			return err
+		}
 		if err != nil {
 			return fmt.Errorf("inserting operations for %s: %v", req.EventName, err)
 		}
@@ -196,40 +276,261 @@ func (s *schemaBackend) CreateSchema(req *scoop_protocol.Config, user string) *c
 		if err != nil {
 			return fmt.Errorf("inserting event metadata for %s: %v", req.EventName, err)
 		}
+		if ownerTeam != "" {
+			err = insertEventMetadata(tx, req.EventName, OWNER_TEAM, ownerTeam, user, 1)
+			if err != nil {
+				return fmt.Errorf("inserting owner team for %s: %v", req.EventName, err)
+			}
+		}
 		return nil
-	}, s.db))
+	}, s.db)
+	if err == errVersionTaken {
+		return core.NewUserWebErrorf("Table already exists (check underscores/hyphens)")
+	}
+	return core.NewServerWebError(err)
 }
 
-// UpdateSchema validates that the update operation is valid and if so, stores
-// the operations for this migration to the schema as operations in bpdb. It
-// applies the operations in order of delete, add, then renames.
-func (s *schemaBackend) UpdateSchema(req *core.ClientUpdateSchemaRequest, user string) *core.WebError {
+// prepareCreate validates a schema creation request, returning the operations
+// that would create the schema.
+func (s *schemaBackend) prepareCreate(req *core.ClientCreateSchemaRequest) ([]scoop_protocol.Operation, *core.WebError) {
+	exists, err := s.looseSchemaExists(req.EventName)
+	if err != nil {
+		return nil, core.NewServerWebErrorf("checking for schema existence: %v", err)
+	}
+	if exists {
+		return nil, core.NewUserWebErrorf("Table already exists (check underscores/hyphens)")
+	}
+	err = preValidateSchema(&req.Config)
+	if err != nil {
+		return nil, core.NewUserWebError(err)
+	}
+	err = validateCreatePII(req)
+	if err != nil {
+		return nil, core.NewUserWebError(err)
+	}
+	return schemaCreateRequestToOps(&req.Config, req.PII), nil
+}
+
+// PreviewCreateSchema validates a schema creation request and returns the
+// schema it would create, without storing anything.
+func (s *schemaBackend) PreviewCreateSchema(req *core.ClientCreateSchemaRequest) (*SchemaPreview, *core.WebError) {
+	ops, webErr := s.prepareCreate(req)
+	if webErr != nil {
+		return nil, webErr
+	}
+	schema := &AnnotatedSchema{EventName: req.EventName}
+	if err := ApplyOperations(schema, ops); err != nil {
+		return nil, core.NewServerWebErrorf("error applying create operations: %v", err)
+	}
+	return newSchemaPreview(schema, ops, 0, nil), nil
+}
+
+// prepareUpdate validates an update request against the current schema. It
+// returns the schema as it would be after the update, the operations that
+// would update it and the version the update was validated against.
+func (s *schemaBackend) prepareUpdate(req *core.ClientUpdateSchemaRequest) (*AnnotatedSchema, []scoop_protocol.Operation, int, *core.WebError) {
 	schema, err := s.Schema(req.EventName, nil)
 	if err != nil {
-		return core.NewServerWebErrorf("error getting schema to validate schema update: %v", err)
+		return nil, nil, 0, core.NewServerWebErrorf("error getting schema to validate schema update: %v", err)
 	}
 	if schema == nil {
-		return core.NewUserWebError(errors.New("schema does not exist"))
+		return nil, nil, 0, core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	baseVersion := schema.Version
+	if req.BaseVersion != nil {
+		if *req.BaseVersion < 0 || *req.BaseVersion > schema.Version {
+			return nil, nil, 0, core.NewUserWebErrorf("base version must be between 0 and %d", schema.Version)
+		}
+		if *req.BaseVersion != schema.Version {
+			return nil, nil, 0, s.versionConflict(req.EventName, *req.BaseVersion)
+		}
 	}
 	requestErr := preValidateUpdate(req, schema)
 	if requestErr != "" {
-		return core.NewUserWebError(errors.New(requestErr))
+		return nil, nil, 0, core.NewUserWebError(errors.New(requestErr))
 	}
 	ops := schemaUpdateRequestToOps(req)
 	err = ApplyOperations(schema, ops)
 	if err != nil {
-		return core.NewServerWebErrorf("error applying update operations: %v", err)
+		return nil, nil, 0, core.NewServerWebErrorf("error applying update operations: %v", err)
 	}
+	return schema, ops, baseVersion, nil
+}
 
-	return core.NewServerWebError(execFnInTransaction(func(tx *sql.Tx) error {
-		row := tx.QueryRow(nextVersionQuery, req.EventName)
-		var newVersion int
-		err := row.Scan(&newVersion)
-		if err != nil {
-			return fmt.Errorf("parsing response for version number for %s: %v", req.EventName, err)
+// PreviewUpdateSchema validates an update request and returns the schema it
+// would result in, without storing anything.
+func (s *schemaBackend) PreviewUpdateSchema(req *core.ClientUpdateSchemaRequest) (*SchemaPreview, *core.WebError) {
+	schema, ops, baseVersion, webErr := s.prepareUpdate(req)
+	if webErr != nil {
+		return nil, webErr
+	}
+	return newSchemaPreview(schema, ops, baseVersion+1, updateWarnings(req)), nil
+}
+
+// UpdateSchema validates that the update operation is valid and if so, stores
+// the operations for this migration to the schema as operations in bpdb. It
+// applies the operations in order of delete, retype, add, then renames.
+func (s *schemaBackend) UpdateSchema(req *core.ClientUpdateSchemaRequest, user string) *core.WebError {
+	_, ops, baseVersion, webErr := s.prepareUpdate(req)
+	if webErr != nil {
+		return webErr
+	}
+	err := execFnInTransaction(func(tx *sql.Tx) error {
+		_, err := insertUpdate(tx, ops, baseVersion, req.EventName, user)
+		return err
+	}, s.db)
+	if err == errVersionTaken {
+		return s.versionConflict(req.EventName, baseVersion)
+	}
+	return core.NewServerWebError(err)
+}
+
+// BulkUpdateResult is the outcome of the update to one event in a bulk
+// update. Version is the event's version after the update if the updates were
+// applied, and Error is why the update is invalid if they were not.
+type BulkUpdateResult struct {
+	EventName string
+	Version   int
+	Error     string       `json:",omitempty"`
+	Lint      []LintResult `json:",omitempty"`
+}
+
+// UpdateSchemas validates all of the updates and, only if they are all valid,
+// stores them in a single transaction. Invalid updates are reported in the
+// results rather than as an error, and mean none of the updates are applied.
+func (s *schemaBackend) UpdateSchemas(reqs []*core.ClientUpdateSchemaRequest, user string) ([]BulkUpdateResult, *core.WebError) {
+	if len(reqs) == 0 {
+		return nil, core.NewUserWebErrorf("no updates given")
+	}
+	results := make([]BulkUpdateResult, len(reqs))
+	ops := make([][]scoop_protocol.Operation, len(reqs))
+	baseVersions := make([]int, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	invalid := false
+	for i, req := range reqs {
+		results[i].EventName = req.EventName
+		if seen[req.EventName] {
+			results[i].Error = "event is updated more than once"
+			invalid = true
+			continue
 		}
-		return insertOperations(tx, ops, newVersion, req.EventName, user)
-	}, s.db))
+		seen[req.EventName] = true
+
+		var webErr *core.WebError
+		_, ops[i], baseVersions[i], webErr = s.prepareUpdate(req)
+		switch {
+		case webErr == nil:
+		case webErr.ServerError != nil:
+			return nil, core.AnnotateWebError("validating update to "+req.EventName, webErr)
+		case webErr.ConflictError != nil:
+			results[i].Error = webErr.ConflictError.Error()
+			invalid = true
+		default:
+			results[i].Error = webErr.UserError.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		return results, nil
+	}
+
+	var taken string
+	err := execFnInTransaction(func(tx *sql.Tx) error {
+		for i, req := range reqs {
+			newVersion, err := insertUpdate(tx, ops[i], baseVersions[i], req.EventName, user)
+			if err == errVersionTaken {
+				taken = req.EventName
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("updating %s: %v", req.EventName, err)
+			}
+			results[i].Version = newVersion
+		}
+		return nil
+	}, s.db)
+	if err == errVersionTaken {
+		return nil, core.NewUserWebErrorf("schema %s was updated during the bulk update, so none were applied", taken)
+	}
+	if err != nil {
+		return nil, core.NewServerWebError(err)
+	}
+	return results, nil
+}
+
+// versionConflict returns a conflict error describing the operations applied
+// to `eventName` since `baseVersion`.
+func (s *schemaBackend) versionConflict(eventName string, baseVersion int) *core.WebError {
+	current, err := s.Schema(eventName, nil)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting schema to report version conflict: %v", err)
+	}
+	if current == nil {
+		return core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	ops, err := s.Migration(eventName, baseVersion, current.Version)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting operations to report version conflict: %v", err)
+	}
+	return core.NewConflictWebError(&core.VersionConflict{
+		EventName:      eventName,
+		BaseVersion:    baseVersion,
+		CurrentVersion: current.Version,
+		Operations:     ops,
+	})
+}
+
+// RevertSchema restores the columns of `eventName` to how they were at version
+// `toVersion`. The inverse operations are stored as a new version, so the
+// revert itself can be migrated and reverted like any other update. Unless
+// `force` is set, the revert is rejected if it would delete columns that are
+// not deprecated past their sunset.
+func (s *schemaBackend) RevertSchema(eventName string, toVersion int, user string, force bool) *core.WebError {
+	current, err := s.Schema(eventName, nil)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting schema to revert: %v", err)
+	}
+	if current == nil {
+		return core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	if toVersion < 0 || toVersion >= current.Version {
+		return core.NewUserWebErrorf("version to revert to must be between 0 and %d", current.Version-1)
+	}
+	target, err := s.Schema(eventName, &toVersion)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting version %d of schema to revert to: %v", toVersion, err)
+	}
+	if target == nil {
+		return core.NewUserWebErrorf("schema was dropped at version %d", toVersion)
+	}
+	migration, err := s.Migration(eventName, toVersion, current.Version)
+	if err != nil {
+		return core.NewServerWebErrorf("error getting operations to revert: %v", err)
+	}
+	ops := make([]scoop_protocol.Operation, 0, len(migration))
+	for _, op := range migration {
+		ops = append(ops, *op)
+	}
+
+	req := revertRequest(target, current, ops)
+	req.BaseVersion = &current.Version
+	if len(req.Additions)+len(req.Deletes)+len(req.Renames)+len(req.Retypes) == 0 {
+		return core.NewUserWebErrorf("columns at version %d are the same as the current version", toVersion)
+	}
+	if err = validateRevertRetypes(req, current); err != nil {
+		return core.NewUserWebErrorf("cannot revert to version %d: %v", toVersion, err)
+	}
+	if !force {
+		if names := undeprecatedDeletes(req, current, time.Now().UTC()); len(names) > 0 {
+			return core.NewUserWebErrorf("reverting to version %d deletes columns that must be deprecated past their sunset first: %s",
+				toVersion, strings.Join(names, ", "))
+		}
+	}
+	req.ForceDelete = force
+	if webErr := s.UpdateSchema(req, user); webErr != nil {
+		return core.AnnotateWebError(fmt.Sprintf("reverting to version %d", toVersion), webErr)
+	}
+	return nil
 }
 
 // DropSchema drops or requests a drop for a schema, depending on whether it exists according to ingester.
@@ -251,6 +552,70 @@ func (s *schemaBackend) DropSchema(schema *AnnotatedSchema, reason string, exist
 	}, s.db)
 }
 
+// prepareRestore validates a restore of the dropped schema `eventName`,
+// returning the schema as it would be after the restore, the operations that
+// would restore it and the version of the dropped schema.
+func (s *schemaBackend) prepareRestore(eventName string, reason string) (*AnnotatedSchema, []scoop_protocol.Operation, int, *core.WebError) {
+	if reason == "" {
+		return nil, nil, 0, core.NewUserWebErrorf("a reason is required to restore a schema")
+	}
+	rows, err := s.db.Query(schemaQuery, eventName)
+	if err != nil {
+		return nil, nil, 0, core.NewServerWebErrorf("querying for schema %s: %v", eventName, err)
+	}
+	opRows, err := scanOperationRows(rows)
+	if err != nil {
+		return nil, nil, 0, core.NewServerWebError(err)
+	}
+	if len(opRows) == 0 {
+		return nil, nil, 0, core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	ops, err := restoreOperations(opRows, reason)
+	if err != nil {
+		return nil, nil, 0, core.NewUserWebError(err)
+	}
+	schema := &AnnotatedSchema{EventName: eventName}
+	if err = ApplyOperations(schema, ops); err != nil {
+		return nil, nil, 0, core.NewServerWebErrorf("error applying restore operations: %v", err)
+	}
+	return schema, ops, opRows[len(opRows)-1].version, nil
+}
+
+// PreviewRestoreSchema validates a restore of the dropped schema `eventName`
+// and returns the schema it would result in, without storing anything.
+func (s *schemaBackend) PreviewRestoreSchema(eventName string, reason string) (*SchemaPreview, *core.WebError) {
+	schema, ops, droppedVersion, webErr := s.prepareRestore(eventName, reason)
+	if webErr != nil {
+		return nil, webErr
+	}
+	return newSchemaPreview(schema, ops, droppedVersion+1, nil), nil
+}
+
+// RestoreSchema re-adds the columns a dropped schema had just before it was
+// dropped, as a new version recording who restored it and why.
+func (s *schemaBackend) RestoreSchema(eventName string, reason string, user string) *core.WebError {
+	_, ops, droppedVersion, webErr := s.prepareRestore(eventName, reason)
+	if webErr != nil {
+		return webErr
+	}
+	err := execFnInTransaction(func(tx *sql.Tx) error {
+		row := tx.QueryRow(nextVersionQuery, eventName)
+		var newVersion int
+		err := row.Scan(&newVersion)
+		if err != nil {
+			return fmt.Errorf("parsing response for version number for %s: %v", eventName, err)
+		}
+		if newVersion != droppedVersion+1 {
+			return errVersionTaken
+		}
+		return insertOperations(tx, ops, newVersion, eventName, user)
+	}, s.db)
+	if err == errVersionTaken {
+		return core.NewUserWebErrorf("schema %s was modified during the restore", eventName)
+	}
+	return core.NewServerWebError(err)
+}
+
 // looseSchemaExists checks if a schema name exists in blueprint already, replacing '-' with '_'
 func (s *schemaBackend) looseSchemaExists(eventName string) (bool, error) {
 	row := s.db.QueryRow(looseSchemaExistsQuery, eventName)
@@ -289,11 +654,22 @@ func scanOperationRows(rows *sql.Rows) ([]operationRow, error) {
 
 // Schema returns the schema for the table `name`
 // The version parameter can be used to request the specific version of the schema (0 to the current version)
-// If nil is the argument given for version, then Schema() returns the current version of the schema
+// If nil is the argument given for version, then Schema() returns the current version of the schema,
+// read from its latest snapshot if it is up to date
 func (s *schemaBackend) Schema(name string, version *int) (*AnnotatedSchema, error) {
 	var rows *sql.Rows
 	var err error
 	if version == nil {
+		snapshot, snapshotErr := latestSnapshot(s.db, name)
+		if snapshotErr != nil {
+			return nil, snapshotErr
+		}
+		if snapshot != nil {
+			if snapshot.Dropped {
+				return nil, nil
+			}
+			return snapshot, nil
+		}
 		rows, err = s.db.Query(schemaQuery, name)
 	} else {
 		rows, err = s.db.Query(schemaQueryWithVersion, name, *version)
@@ -319,8 +695,100 @@ func (s *schemaBackend) Schema(name string, version *int) (*AnnotatedSchema, err
 	return &schemas[0], nil
 }
 
-// Schema returns all of the current schemas
+// SchemaDiff returns the difference between versions `from` and `to` of the
+// schema `name`, or nil if the schema does not exist.
+func (s *schemaBackend) SchemaDiff(name string, from int, to int) (*SchemaDiff, error) {
+	rows, err := s.db.Query(schemaQueryWithVersion, name, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying for schema %s: %v", name, err)
+	}
+	ops, err := scanOperationRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	return diffSchemas(name, ops, from, to)
+}
+
+// SchemaHistory returns up to `limit` versions of the schema `name`, newest
+// first, skipping the newest `offset` versions. It returns nil if the schema
+// does not exist.
+func (s *schemaBackend) SchemaHistory(name string, offset int, limit int) (*SchemaHistory, error) {
+	rows, err := s.db.Query(schemaQuery, name)
+	if err != nil {
+		return nil, fmt.Errorf("querying for schema %s: %v", name, err)
+	}
+	ops, err := scanOperationRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	versions, err := buildSchemaHistory(ops)
+	if err != nil {
+		return nil, fmt.Errorf("building history of schema %s: %v", name, err)
+	}
+	history := &SchemaHistory{
+		EventName:     name,
+		TotalVersions: len(versions),
+		Offset:        offset,
+		Limit:         limit,
+		Versions:      []SchemaVersion{},
+	}
+	if offset < len(versions) {
+		end := offset + limit
+		if end > len(versions) {
+			end = len(versions)
+		}
+		history.Versions = versions[offset:end]
+	}
+	return history, nil
+}
+
+// AllSchemas returns all of the current schemas. They are read from the
+// latest snapshot of each event, which is written with every change and
+// backfilled by RebuildSnapshots at startup. If an event has no snapshot, as on
+// a readonly instance that never backfills, or any snapshot is in an outdated
+// format, the whole operation log is replayed instead.
 func (s *schemaBackend) AllSchemas() ([]AnnotatedSchema, error) {
+	rows, err := s.db.Query(allLatestSnapshotsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("querying for all schema snapshots: %v", err)
+	}
+	snapshots, err := scanSnapshots(rows)
+	if err != nil {
+		return nil, err
+	}
+	var events int
+	err = s.db.QueryRow(operationEventCountQuery).Scan(&events)
+	if err != nil {
+		return nil, fmt.Errorf("counting events in the operation log: %v", err)
+	}
+	if len(snapshots) != events {
+		logger.WithField("snapshots", len(snapshots)).
+			WithField("events", events).
+			Warn("Schema snapshots missing, replaying operation log")
+		return s.replayAllSchemas()
+	}
+	schemas := make([]AnnotatedSchema, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.Format != snapshotFormat {
+			logger.WithField("event", snapshot.Schema.EventName).Warn("Schema snapshot stale, replaying operation log")
+			return s.replayAllSchemas()
+		}
+		if !snapshot.Schema.Dropped {
+			schemas = append(schemas, *snapshot.Schema)
+		}
+	}
+	return schemas, nil
+}
+
+// replayAllSchemas rebuilds the schemas that have not been dropped from the
+// whole operation log.
+func (s *schemaBackend) replayAllSchemas() ([]AnnotatedSchema, error) {
 	rows, err := s.db.Query(allSchemasQuery)
 	if err != nil {
 		return nil, fmt.Errorf("querying for all schemas: %v", err)
@@ -329,12 +797,17 @@ func (s *schemaBackend) AllSchemas() ([]AnnotatedSchema, error) {
 	if err != nil {
 		return nil, err
 	}
-	return generateSchemas(ops)
+	schemas, err := generateSchemas(ops)
+	if err != nil {
+		return nil, fmt.Errorf("generating schemas from operations: %v", err)
+	}
+	return schemas, nil
 }
 
-// generateSchemas creates schemas from a list of operations
-// by applying the operations in the order they appear in the array
-func generateSchemas(ops []operationRow) ([]AnnotatedSchema, error) {
+// replaySchemas creates schemas, including dropped ones, from a list of
+// operations by applying the operations in the order they appear in the
+// array. The schemas are returned sorted by event name.
+func replaySchemas(ops []operationRow) ([]*AnnotatedSchema, error) {
 	schemas := make(map[string]*AnnotatedSchema)
 	for _, op := range ops {
 		_, exists := schemas[op.event]
@@ -347,7 +820,7 @@ func generateSchemas(ops []operationRow) ([]AnnotatedSchema, error) {
 			Name:           op.name,
 		})
 		if err != nil {
-			return []AnnotatedSchema{}, fmt.Errorf("applying operation to schema: %v", err)
+			return nil, fmt.Errorf("applying operation to schema: %v", err)
 		}
 		if op.version >= schemas[op.event].Version {
 			schemas[op.event].Version = op.version
@@ -355,8 +828,26 @@ func generateSchemas(ops []operationRow) ([]AnnotatedSchema, error) {
 			schemas[op.event].UserName = op.userName
 		}
 	}
-	ret := make([]AnnotatedSchema, 0, len(schemas))
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([]*AnnotatedSchema, 0, len(schemas))
+	for _, name := range names {
+		ret = append(ret, schemas[name])
+	}
+	return ret, nil
+}
 
+// generateSchemas creates the schemas that have not been dropped from a list
+// of operations by applying the operations in the order they appear in the array
+func generateSchemas(ops []operationRow) ([]AnnotatedSchema, error) {
+	schemas, err := replaySchemas(ops)
+	if err != nil {
+		return []AnnotatedSchema{}, err
+	}
+	ret := make([]AnnotatedSchema, 0, len(schemas))
 	for _, val := range schemas {
 		if !val.Dropped {
 			ret = append(ret, *val)
@@ -427,6 +918,32 @@ func (s *schemaBackend) AllEventMetadata() (*AllEventMetadata, error) {
 	return &AllEventMetadata{Metadata: allMetadata}, nil
 }
 
+// EventMetadataHistory returns every version of every type of the event's
+// metadata, newest first within each type.
+func (s *schemaBackend) EventMetadataHistory(eventName string) ([]EventMetadataChange, error) {
+	rows, err := s.db.Query(eventMetadataHistoryQuery, eventName)
+	if err != nil {
+		return nil, fmt.Errorf("querying for metadata history of %s: %v", eventName, err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.WithError(err).Error("closing rows in postgres backend EventMetadataHistory")
+		}
+	}()
+
+	history := []EventMetadataChange{}
+	for rows.Next() {
+		var change EventMetadataChange
+		err := rows.Scan(&change.MetadataType, &change.MetadataValue, &change.TS, &change.UserName, &change.Version)
+		if err != nil {
+			return nil, fmt.Errorf("parsing event_metadata row: %v", err)
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
 func insertEventMetadata(tx *sql.Tx, eventName string, metadataType scoop_protocol.EventMetadataType, value string, user string, version int) error {
 	if _, err := tx.Exec(insertEventMetadataQuery, eventName, string(metadataType), value, user, version); err != nil {
 		return fmt.Errorf("INSERTing event_metadata row on %s: %v", eventName, err)
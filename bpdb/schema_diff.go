@@ -0,0 +1,183 @@
+package bpdb
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// Kinds of change a column can undergo between two versions of a schema.
+const (
+	ColumnAdded    = "added"
+	ColumnRemoved  = "removed"
+	ColumnRenamed  = "renamed"
+	ColumnModified = "modified"
+)
+
+// States a schema can be in with respect to being dropped.
+const (
+	SchemaActive        = "active"
+	SchemaDropRequested = "drop_requested"
+	SchemaDropped       = "dropped"
+)
+
+// ColumnDiff is the change to a single column between two versions of a schema.
+// Before is nil for added columns and After is nil for removed columns.
+type ColumnDiff struct {
+	Before  *scoop_protocol.ColumnDefinition
+	After   *scoop_protocol.ColumnDefinition
+	Changes []string
+}
+
+// DropTransition is a drop request, drop or drop cancellation between two
+// versions of a schema.
+type DropTransition struct {
+	Version  int
+	Action   scoop_protocol.Action
+	Reason   string
+	TS       time.Time
+	UserName string
+}
+
+// SchemaDiff is the difference in state between two versions of a schema.
+type SchemaDiff struct {
+	EventName       string
+	FromVersion     int
+	ToVersion       int
+	FromState       string
+	ToState         string
+	Columns         []ColumnDiff
+	DropTransitions []DropTransition
+}
+
+// dropState returns whether the schema is active, drop requested or dropped.
+func dropState(s *AnnotatedSchema) string {
+	switch {
+	case s.Dropped:
+		return SchemaDropped
+	case s.DropRequested:
+		return SchemaDropRequested
+	default:
+		return SchemaActive
+	}
+}
+
+// diffSchemas rebuilds the `from` and `to` versions of a schema from its
+// operation rows and returns the difference between them. Rows must be for a
+// single event and ordered by version and ordering.
+func diffSchemas(eventName string, rows []operationRow, from, to int) (*SchemaDiff, error) {
+	before := &AnnotatedSchema{EventName: eventName}
+	after := &AnnotatedSchema{EventName: eventName}
+	var between []scoop_protocol.Operation
+	diff := &SchemaDiff{EventName: eventName, FromVersion: from, ToVersion: to}
+	for _, row := range rows {
+		if row.version > to {
+			break
+		}
+		op := scoop_protocol.Operation{
+			Action:         scoop_protocol.Action(row.action),
+			ActionMetadata: row.actionMetadata,
+			Name:           row.name,
+		}
+		if row.version <= from {
+			if err := ApplyOperation(before, op); err != nil {
+				return nil, fmt.Errorf("applying operation to version %d: %v", from, err)
+			}
+		} else {
+			between = append(between, op)
+			switch op.Action {
+			case scoop_protocol.REQUEST_DROP_EVENT, scoop_protocol.DROP_EVENT, scoop_protocol.CANCEL_DROP_EVENT:
+				diff.DropTransitions = append(diff.DropTransitions, DropTransition{
+					Version:  row.version,
+					Action:   op.Action,
+					Reason:   op.ActionMetadata["reason"],
+					TS:       row.ts,
+					UserName: row.userName,
+				})
+			}
+		}
+		if err := ApplyOperation(after, op); err != nil {
+			return nil, fmt.Errorf("applying operation to version %d: %v", to, err)
+		}
+	}
+	diff.FromState = dropState(before)
+	diff.ToState = dropState(after)
+
+	afterCols := make(map[string]scoop_protocol.ColumnDefinition, len(after.Columns))
+	for _, col := range after.Columns {
+		afterCols[col.OutboundName] = col
+	}
+	trace := traceColumns(before, between)
+	traced := make(map[string]bool, len(trace))
+	for i := range before.Columns {
+		prev := before.Columns[i]
+		name := trace[prev.OutboundName]
+		if name == "" {
+			diff.Columns = append(diff.Columns, ColumnDiff{Before: &prev, Changes: []string{ColumnRemoved}})
+			continue
+		}
+		traced[name] = true
+		next := afterCols[name]
+		var changes []string
+		if name != prev.OutboundName {
+			changes = append(changes, ColumnRenamed)
+		}
+		if next.InboundName != prev.InboundName ||
+			next.Transformer != prev.Transformer ||
+			next.ColumnCreationOptions != prev.ColumnCreationOptions ||
+			next.SupportingColumns != prev.SupportingColumns {
+			changes = append(changes, ColumnModified)
+		}
+		if len(changes) > 0 {
+			diff.Columns = append(diff.Columns, ColumnDiff{Before: &prev, After: &next, Changes: changes})
+		}
+	}
+	for i := range after.Columns {
+		next := after.Columns[i]
+		if !traced[next.OutboundName] {
+			diff.Columns = append(diff.Columns, ColumnDiff{After: &next, Changes: []string{ColumnAdded}})
+		}
+	}
+	return diff, nil
+}
+
+func formatDiffColumn(col *scoop_protocol.ColumnDefinition) string {
+	line := fmt.Sprintf("%s %s%s <- %s", col.OutboundName, col.Transformer, col.ColumnCreationOptions, col.InboundName)
+	if col.SupportingColumns != "" {
+		line += fmt.Sprintf(" (supporting: %s)", col.SupportingColumns)
+	}
+	return line
+}
+
+// UnifiedText renders the diff in the style of a unified diff, suitable for
+// pasting into code review comments.
+func (d *SchemaDiff) UnifiedText() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- %s v%d\n", d.EventName, d.FromVersion)
+	fmt.Fprintf(&b, "+++ %s v%d\n", d.EventName, d.ToVersion)
+	if d.FromState != d.ToState {
+		fmt.Fprintf(&b, "@@ state: %s -> %s @@\n", d.FromState, d.ToState)
+	}
+	for _, t := range d.DropTransitions {
+		fmt.Fprintf(&b, "# v%d %s by %s", t.Version, t.Action, t.UserName)
+		if t.Reason != "" {
+			fmt.Fprintf(&b, ": %s", t.Reason)
+		}
+		b.WriteString("\n")
+	}
+	for _, col := range d.Columns {
+		if col.Before != nil {
+			fmt.Fprintf(&b, "-%s\n", formatDiffColumn(col.Before))
+		}
+		if col.After != nil {
+			fmt.Fprintf(&b, "+%s", formatDiffColumn(col.After))
+			if col.Before != nil && col.Before.OutboundName != col.After.OutboundName {
+				fmt.Fprintf(&b, " # renamed from %s", col.Before.OutboundName)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func operationRows(version int, ops ...scoop_protocol.Operation) []operationRow {
+	rows := make([]operationRow, 0, len(ops))
+	for i, op := range ops {
+		rows = append(rows, operationRow{
+			event:          "test",
+			action:         string(op.Action),
+			name:           op.Name,
+			actionMetadata: op.ActionMetadata,
+			version:        version,
+			ordering:       i,
+			userName:       "tester",
+		})
+	}
+	return rows
+}
+
+func TestDiffSchemas(t *testing.T) {
+	require := require.New(t)
+	var rows []operationRow
+	rows = append(rows, operationRows(0,
+		scoop_protocol.NewAddOperation("time", "time", "f@timestamp@unix", "", ""),
+		scoop_protocol.NewAddOperation("backend", "backend", "varchar", "(32)", ""),
+		scoop_protocol.NewAddOperation("quality", "quality", "varchar", "(16)", ""))...)
+	rows = append(rows, operationRows(1,
+		scoop_protocol.NewRenameOperation("quality", "video_quality"))...)
+	rows = append(rows, operationRows(2,
+		scoop_protocol.NewDeleteOperation("backend"),
+		scoop_protocol.NewAddOperation("os", "os", "varchar", "(16)", ""),
+		scoop_protocol.NewRenameOperation("video_quality", "player_quality"))...)
+	rows = append(rows, operationRows(3,
+		scoop_protocol.NewRequestDropEventOperation("unused"))...)
+	rows = append(rows, operationRows(4,
+		scoop_protocol.NewCancelDropEventOperation(""))...)
+
+	diff, err := diffSchemas("test", rows, 0, 4)
+	require.Nil(err)
+	require.Equal(SchemaActive, diff.FromState)
+	require.Equal(SchemaActive, diff.ToState)
+	require.Len(diff.DropTransitions, 2)
+	require.Equal(scoop_protocol.REQUEST_DROP_EVENT, diff.DropTransitions[0].Action)
+	require.Equal("unused", diff.DropTransitions[0].Reason)
+	require.Equal(scoop_protocol.CANCEL_DROP_EVENT, diff.DropTransitions[1].Action)
+
+	require.Len(diff.Columns, 3)
+	require.Equal([]string{ColumnRemoved}, diff.Columns[0].Changes)
+	require.Equal("backend", diff.Columns[0].Before.OutboundName)
+	require.Nil(diff.Columns[0].After)
+	require.Equal([]string{ColumnRenamed}, diff.Columns[1].Changes)
+	require.Equal("quality", diff.Columns[1].Before.OutboundName)
+	require.Equal("player_quality", diff.Columns[1].After.OutboundName)
+	require.Equal([]string{ColumnAdded}, diff.Columns[2].Changes)
+	require.Equal("os", diff.Columns[2].After.OutboundName)
+
+	require.Equal(`--- test v0
++++ test v4
+# v3 request_drop_event by tester: unused
+# v4 cancel_drop_event by tester
+-backend varchar(32) <- backend
+-quality varchar(16) <- quality
++player_quality varchar(16) <- quality # renamed from quality
++os varchar(16) <- os
+`, diff.UnifiedText())
+
+	diff, err = diffSchemas("test", rows, 2, 3)
+	require.Nil(err)
+	require.Equal(SchemaActive, diff.FromState)
+	require.Equal(SchemaDropRequested, diff.ToState)
+	require.Empty(diff.Columns)
+}
@@ -2,7 +2,10 @@ package bpdb
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/twitchscience/blueprint/core"
 	"github.com/twitchscience/scoop_protocol/scoop_protocol"
 )
 
@@ -35,6 +38,11 @@ func ApplyOperation(s *AnnotatedSchema, op scoop_protocol.Operation) error {
 			ColumnCreationOptions: op.ActionMetadata["column_options"],
 			SupportingColumns:     op.ActionMetadata["supporting_columns"],
 		})
+		class, ok := op.ActionMetadata["pii"]
+		if !ok {
+			class = core.DefaultPIIClass(op.ActionMetadata["column_type"])
+		}
+		setPIIClass(s, op.Name, class)
 		s.Dropped = false
 		s.DropRequested = false
 		s.Reason = ""
@@ -43,6 +51,8 @@ func ApplyOperation(s *AnnotatedSchema, op scoop_protocol.Operation) error {
 			if existingCol.OutboundName == op.Name {
 				// splice the dropped column away
 				s.Columns = append(s.Columns[:i], s.Columns[i+1:]...)
+				delete(s.Deprecations, op.Name)
+				delete(s.PII, op.Name)
 				return nil
 			}
 		}
@@ -51,10 +61,52 @@ func ApplyOperation(s *AnnotatedSchema, op scoop_protocol.Operation) error {
 		for i, existingCol := range s.Columns {
 			if existingCol.OutboundName == op.Name {
 				s.Columns[i].OutboundName = op.ActionMetadata["new_outbound"]
+				if deprecation, ok := s.Deprecations[op.Name]; ok {
+					delete(s.Deprecations, op.Name)
+					s.Deprecations[op.ActionMetadata["new_outbound"]] = deprecation
+				}
+				if class, ok := s.PII[op.Name]; ok {
+					delete(s.PII, op.Name)
+					s.PII[op.ActionMetadata["new_outbound"]] = class
+				}
 				return nil
 			}
 		}
 		return fmt.Errorf("outbound column '%s' does not exists in schema, cannot rename non-existent column", op.Name)
+	case RETYPE:
+		for i, existingCol := range s.Columns {
+			if existingCol.OutboundName == op.Name {
+				s.Columns[i].Transformer = op.ActionMetadata["column_type"]
+				s.Columns[i].ColumnCreationOptions = op.ActionMetadata["column_options"]
+				if _, ok := s.PII[op.Name]; !ok {
+					setPIIClass(s, op.Name, core.DefaultPIIClass(op.ActionMetadata["column_type"]))
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("outbound column '%s' does not exists in schema, cannot retype non-existent column", op.Name)
+	case DEPRECATE:
+		for _, existingCol := range s.Columns {
+			if existingCol.OutboundName == op.Name {
+				if s.Deprecations == nil {
+					s.Deprecations = make(map[string]ColumnDeprecation)
+				}
+				s.Deprecations[op.Name] = ColumnDeprecation{
+					Reason: op.ActionMetadata["reason"],
+					Sunset: op.ActionMetadata["sunset"],
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("outbound column '%s' does not exists in schema, cannot deprecate non-existent column", op.Name)
+	case CLASSIFY:
+		for _, existingCol := range s.Columns {
+			if existingCol.OutboundName == op.Name {
+				setPIIClass(s, op.Name, op.ActionMetadata["pii"])
+				return nil
+			}
+		}
+		return fmt.Errorf("outbound column '%s' does not exists in schema, cannot classify non-existent column", op.Name)
 	case scoop_protocol.REQUEST_DROP_EVENT:
 		s.DropRequested = true
 		s.Reason = op.ActionMetadata["reason"]
@@ -62,6 +114,8 @@ func ApplyOperation(s *AnnotatedSchema, op scoop_protocol.Operation) error {
 		s.DropRequested = false
 		s.Dropped = true
 		s.Columns = []scoop_protocol.ColumnDefinition{}
+		s.Deprecations = nil
+		s.PII = nil
 		if s.Reason == "" {
 			s.Reason = op.ActionMetadata["reason"]
 		}
@@ -73,3 +127,188 @@ func ApplyOperation(s *AnnotatedSchema, op scoop_protocol.Operation) error {
 	}
 	return nil
 }
+
+// setPIIClass records the PII class of a column, only keeping sensitive ones.
+func setPIIClass(s *AnnotatedSchema, outbound, class string) {
+	if class == core.PIINone {
+		delete(s.PII, outbound)
+		return
+	}
+	if s.PII == nil {
+		s.PII = make(map[string]string)
+	}
+	s.PII[outbound] = class
+}
+
+// traceColumns follows the columns of base through the given operations. It
+// returns a map from each of base's outbound column names to that column's
+// outbound name after the operations, or to "" if the column was deleted.
+func traceColumns(base *AnnotatedSchema, operations []scoop_protocol.Operation) map[string]string {
+	origins := make(map[string]string, len(base.Columns))
+	for _, col := range base.Columns {
+		origins[col.OutboundName] = col.OutboundName
+	}
+	for _, op := range operations {
+		switch op.Action {
+		case scoop_protocol.DELETE:
+			delete(origins, op.Name)
+		case scoop_protocol.RENAME:
+			if origin, ok := origins[op.Name]; ok {
+				delete(origins, op.Name)
+				origins[op.ActionMetadata["new_outbound"]] = origin
+			}
+		case scoop_protocol.DROP_EVENT:
+			origins = map[string]string{}
+		}
+	}
+
+	trace := make(map[string]string, len(base.Columns))
+	for _, col := range base.Columns {
+		trace[col.OutboundName] = ""
+	}
+	for current, origin := range origins {
+		trace[origin] = current
+	}
+	return trace
+}
+
+// revertRequest builds the update request that migrates current back to
+// target, given the operations that were applied to target to produce
+// current. Columns added since target are deleted, columns deleted since
+// target are re-added, columns renamed since target are renamed back and
+// columns retyped since target are retyped back.
+func revertRequest(target, current *AnnotatedSchema, operations []scoop_protocol.Operation) *core.ClientUpdateSchemaRequest {
+	req := &core.ClientUpdateSchemaRequest{
+		EventName: current.EventName,
+		Additions: []core.Column{},
+		Deletes:   []string{},
+		Renames:   core.Renames{},
+		Retypes:   []core.Retype{},
+	}
+	currentCols := make(map[string]scoop_protocol.ColumnDefinition, len(current.Columns))
+	for _, col := range current.Columns {
+		currentCols[col.OutboundName] = col
+	}
+	trace := traceColumns(target, operations)
+	survivors := make(map[string]bool, len(trace))
+	for _, col := range target.Columns {
+		name := trace[col.OutboundName]
+		switch {
+		case name == "":
+			req.Additions = append(req.Additions, core.Column{
+				InboundName:       col.InboundName,
+				OutboundName:      col.OutboundName,
+				Transformer:       col.Transformer,
+				Options:           core.ColumnOptionsFromString(col.ColumnCreationOptions),
+				SupportingColumns: col.SupportingColumns,
+			})
+		case name != col.OutboundName:
+			req.Renames[name] = col.OutboundName
+			survivors[name] = true
+		default:
+			survivors[name] = true
+		}
+		if name == "" {
+			continue
+		}
+		if cur := currentCols[name]; cur.Transformer != col.Transformer ||
+			cur.ColumnCreationOptions != col.ColumnCreationOptions {
+			req.Retypes = append(req.Retypes, core.Retype{
+				OutboundName: name,
+				Transformer:  col.Transformer,
+				Options:      core.ColumnOptionsFromString(col.ColumnCreationOptions),
+			})
+		}
+	}
+	for _, col := range current.Columns {
+		if !survivors[col.OutboundName] {
+			req.Deletes = append(req.Deletes, col.OutboundName)
+		}
+	}
+	return req
+}
+
+// validateRevertRetypes returns an error if a retype in the revert request
+// `req` would narrow a column of `schema`. Only widenings can be migrated
+// safely, so a revert cannot undo one.
+func validateRevertRetypes(req *core.ClientUpdateSchemaRequest, schema *AnnotatedSchema) error {
+	for _, retype := range req.Retypes {
+		for _, col := range schema.Columns {
+			if col.OutboundName != retype.OutboundName {
+				continue
+			}
+			options, err := core.ParseColumnOptions(col.ColumnCreationOptions)
+			if err != nil {
+				return fmt.Errorf("column %s has invalid options: %v", col.OutboundName, err)
+			}
+			if err = validateRetype(col.Transformer, options, retype.Transformer, retype.Options); err != nil {
+				return fmt.Errorf("column %s cannot be retyped back to %s: %v", col.OutboundName, retype.Transformer, err)
+			}
+		}
+	}
+	return nil
+}
+
+// undeprecatedDeletes returns the columns that `req` deletes from `schema`
+// which are not deprecated past their sunset at time `now`, sorted by name.
+func undeprecatedDeletes(req *core.ClientUpdateSchemaRequest, schema *AnnotatedSchema, now time.Time) []string {
+	var names []string
+	for _, name := range req.Deletes {
+		if deprecation, ok := schema.Deprecations[name]; !ok || !deprecation.SunsetPassed(now) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// restoreOperations returns the operations that restore a dropped schema to
+// the columns it had just before it was last dropped, given the operation rows
+// of the schema ordered by version and ordering. The columns keep their PII
+// classes and deprecations.
+func restoreOperations(rows []operationRow, reason string) ([]scoop_protocol.Operation, error) {
+	schema := &AnnotatedSchema{}
+	var last AnnotatedSchema
+	for _, row := range rows {
+		op := scoop_protocol.Operation{
+			Action:         scoop_protocol.Action(row.action),
+			ActionMetadata: row.actionMetadata,
+			Name:           row.name,
+		}
+		if op.Action == scoop_protocol.DROP_EVENT {
+			last = *schema
+			last.Columns = append([]scoop_protocol.ColumnDefinition{}, schema.Columns...)
+		}
+		if err := ApplyOperation(schema, op); err != nil {
+			return nil, fmt.Errorf("applying operation of version %d: %v", row.version, err)
+		}
+	}
+	if !schema.Dropped {
+		return nil, fmt.Errorf("schema is not dropped")
+	}
+	if len(last.Columns) == 0 {
+		return nil, fmt.Errorf("schema had no columns before it was dropped")
+	}
+
+	cancel := scoop_protocol.NewCancelDropEventOperation(reason)
+	// The vendored scoop_protocol does not record the reason it is given.
+	cancel.ActionMetadata["reason"] = reason
+	ops := []scoop_protocol.Operation{cancel}
+	for _, col := range last.Columns {
+		class, ok := last.PII[col.OutboundName]
+		if !ok {
+			class = core.PIINone
+		}
+		ops = append(ops, newAddOperation(col, class))
+	}
+	deprecated := make([]string, 0, len(last.Deprecations))
+	for name := range last.Deprecations {
+		deprecated = append(deprecated, name)
+	}
+	sort.Strings(deprecated)
+	for _, name := range deprecated {
+		deprecation := last.Deprecations[name]
+		ops = append(ops, NewDeprecateOperation(name, deprecation.Reason, deprecation.Sunset))
+	}
+	return ops, nil
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/core"
 	"github.com/twitchscience/scoop_protocol/scoop_protocol"
 )
 
@@ -57,6 +60,34 @@ func TestApplyOperationAddColumns(t *testing.T) {
 	}
 }
 
+func TestApplyOperationRetypeColumns(t *testing.T) {
+	base := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			column("minutes_logged", "int", "", ""),
+		},
+	}
+	ops := []scoop_protocol.Operation{
+		NewRetypeOperation("backend", "varchar", "(512)"),
+		NewRetypeOperation("minutes_logged", "bigint", ""),
+	}
+	expected := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 512, ""),
+			bigintColumn("minutes_logged"),
+		},
+	}
+
+	if err := ApplyOperations(&base, ops); err != nil || !reflect.DeepEqual(expected, base) {
+		t.Errorf("Results schema differs from expected:\n%v\nvs\n%v.", base, expected)
+	}
+	if err := ApplyOperation(&base, NewRetypeOperation("os", "varchar", "(16)")); err == nil {
+		t.Error("Expected error on retyping non-existent column.")
+	}
+}
+
 func TestApplyOperationAddDupeColumns(t *testing.T) {
 	base := AnnotatedSchema{
 		EventName: "video_ad_request_error",
@@ -82,3 +113,221 @@ func TestApplyOperationDeleteNonExistentColumns(t *testing.T) {
 		t.Error("Expected error on adding existing row.")
 	}
 }
+
+func TestApplyOperationDeprecate(t *testing.T) {
+	require := require.New(t)
+	schema := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			varcharColumn("quality", 16, ""),
+		},
+	}
+	require.NotNil(ApplyOperation(&schema, NewDeprecateOperation("minutes_logged", "unused", "2030-01-01")))
+
+	require.Nil(ApplyOperations(&schema, []scoop_protocol.Operation{
+		NewDeprecateOperation("backend", "unused", "2030-01-01"),
+		NewDeprecateOperation("quality", "moved to player_quality", "2030-02-01"),
+		scoop_protocol.NewRenameOperation("quality", "video_quality"),
+	}))
+	require.Equal(map[string]ColumnDeprecation{
+		"backend":       {Reason: "unused", Sunset: "2030-01-01"},
+		"video_quality": {Reason: "moved to player_quality", Sunset: "2030-02-01"},
+	}, schema.Deprecations)
+
+	require.Nil(ApplyOperation(&schema, scoop_protocol.NewDeleteOperation("backend")))
+	require.Nil(ApplyOperation(&schema, scoop_protocol.NewAddOperation("backend", "backend", "bigint", "", "")))
+	require.Equal(map[string]ColumnDeprecation{
+		"video_quality": {Reason: "moved to player_quality", Sunset: "2030-02-01"},
+	}, schema.Deprecations, "a re-added column is not deprecated")
+}
+
+func TestApplyOperationClassify(t *testing.T) {
+	require := require.New(t)
+	schema := AnnotatedSchema{EventName: "video_ad_request_error"}
+	add := scoop_protocol.NewAddOperation("email", "email", "varchar", "(64)", "")
+	add.ActionMetadata["pii"] = core.PIIDirectIdentifier
+	require.Nil(ApplyOperations(&schema, []scoop_protocol.Operation{
+		add,
+		scoop_protocol.NewAddOperation("user_id", "user_id", "userIDWithMapping", "", ""),
+		scoop_protocol.NewAddOperation("city", "ip", "ipCity", "", ""),
+		scoop_protocol.NewAddOperation("quality", "quality", "varchar", "(16)", ""),
+	}))
+	require.Equal(map[string]string{
+		"email":   core.PIIDirectIdentifier,
+		"user_id": core.PIIPseudonymous,
+		"city":    core.PIILocation,
+	}, schema.PII, "columns without a class get their transformer's default")
+
+	require.NotNil(ApplyOperation(&schema, NewClassifyOperation("minutes_logged", core.PIINone)))
+	require.Nil(ApplyOperations(&schema, []scoop_protocol.Operation{
+		NewClassifyOperation("email", core.PIINone),
+		NewClassifyOperation("quality", core.PIIDirectIdentifier),
+		scoop_protocol.NewRenameOperation("quality", "video_quality"),
+		scoop_protocol.NewDeleteOperation("city"),
+	}))
+	require.Equal(map[string]string{
+		"user_id":       core.PIIPseudonymous,
+		"video_quality": core.PIIDirectIdentifier,
+	}, schema.PII)
+
+	require.Nil(ApplyOperation(&schema, scoop_protocol.NewDropEventOperation("")))
+	require.Nil(schema.PII)
+}
+
+func TestRevertRequest(t *testing.T) {
+	target := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			varcharColumn("content_mode", 32, ""),
+			varcharColumn("quality", 16, ""),
+		},
+	}
+	ops := []scoop_protocol.Operation{
+		scoop_protocol.NewDeleteOperation("backend"),
+		scoop_protocol.NewAddOperation("minutes_logged", "minutes_logged", "bigint", "", ""),
+		scoop_protocol.NewRenameOperation("quality", "video_quality"),
+		scoop_protocol.NewRenameOperation("video_quality", "player_quality"),
+		scoop_protocol.NewAddOperation("quality", "quality", "bigint", "", ""),
+	}
+	current := target
+	current.Columns = append([]scoop_protocol.ColumnDefinition{}, target.Columns...)
+	if err := ApplyOperations(&current, ops); err != nil {
+		t.Fatalf("Unexpected error applying operations: %v", err)
+	}
+
+	req := revertRequest(&target, &current, ops)
+	expected := &core.ClientUpdateSchemaRequest{
+		EventName: "video_ad_request_error",
+		Additions: []core.Column{{
+			InboundName:  "backend",
+			OutboundName: "backend",
+			Transformer:  "varchar",
+			Options:      core.ColumnOptions{Length: 32},
+		}},
+		Deletes: []string{"minutes_logged", "quality"},
+		Renames: core.Renames{"player_quality": "quality"},
+		Retypes: []core.Retype{},
+	}
+	if !reflect.DeepEqual(expected, req) {
+		t.Errorf("Revert request differs from expected:\n%v\nvs\n%v.", req, expected)
+	}
+	req.ForceDelete = true
+	if requestErr := preValidateUpdate(req, &current); requestErr != "" {
+		t.Fatalf("Unexpected error validating revert: %s", requestErr)
+	}
+	if err := ApplyOperations(&current, schemaUpdateRequestToOps(req)); err != nil {
+		t.Fatalf("Unexpected error applying revert: %v", err)
+	}
+	if !reflect.DeepEqual(columnsByName(target.Columns), columnsByName(current.Columns)) {
+		t.Errorf("Reverted schema differs from target:\n%v\nvs\n%v.", current.Columns, target.Columns)
+	}
+}
+
+func TestRevertRequestWithoutDeprecation(t *testing.T) {
+	require := require.New(t)
+	target := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns:   []scoop_protocol.ColumnDefinition{varcharColumn("backend", 32, "")},
+	}
+	ops := []scoop_protocol.Operation{
+		scoop_protocol.NewAddOperation("minutes_logged", "minutes_logged", "bigint", "", ""),
+		scoop_protocol.NewAddOperation("quality", "quality", "varchar", "(16)", ""),
+	}
+	current := target
+	current.Columns = append([]scoop_protocol.ColumnDefinition{}, target.Columns...)
+	require.Nil(ApplyOperations(&current, ops))
+
+	req := revertRequest(&target, &current, ops)
+	require.Equal([]string{"minutes_logged", "quality"}, req.Deletes)
+	require.False(req.ForceDelete)
+	require.Equal("Column must be deprecated before it can be deleted: minutes_logged", preValidateUpdate(req, &current))
+
+	now := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal([]string{"minutes_logged", "quality"}, undeprecatedDeletes(req, &current, now))
+	require.Nil(ApplyOperations(&current, []scoop_protocol.Operation{
+		NewDeprecateOperation("minutes_logged", "unused", "2017-05-01"),
+		NewDeprecateOperation("quality", "unused", "2017-07-01"),
+	}))
+	require.Equal([]string{"quality"}, undeprecatedDeletes(req, &current, now))
+
+	req.ForceDelete = true
+	require.Equal("", preValidateUpdate(req, &current), "the admin force path deletes without waiting")
+}
+
+func TestRevertRequestPastRetype(t *testing.T) {
+	require := require.New(t)
+	target := AnnotatedSchema{
+		EventName: "video_ad_request_error",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			column("minutes_logged", "int", "", ""),
+		},
+	}
+	ops := []scoop_protocol.Operation{
+		NewRetypeOperation("backend", "varchar", "(64)"),
+		NewRetypeOperation("minutes_logged", "bigint", ""),
+	}
+	current := target
+	current.Columns = append([]scoop_protocol.ColumnDefinition{}, target.Columns...)
+	require.Nil(ApplyOperations(&current, ops))
+
+	req := revertRequest(&target, &current, ops)
+	require.Equal([]core.Retype{
+		{OutboundName: "backend", Transformer: "varchar", Options: core.ColumnOptions{Length: 32}},
+		{OutboundName: "minutes_logged", Transformer: "int"},
+	}, req.Retypes)
+	err := validateRevertRetypes(req, &current)
+	require.NotNil(err)
+	require.Equal("column backend cannot be retyped back to varchar: varchar length can only be increased, given 64 to 32", err.Error())
+	require.Equal("Cannot retype column backend: varchar length can only be increased, given 64 to 32", preValidateUpdate(req, &current))
+
+	req.Retypes = req.Retypes[1:]
+	err = validateRevertRetypes(req, &current)
+	require.NotNil(err)
+	require.Equal("column minutes_logged cannot be retyped back to int: cannot change type from bigint to int, only widening varchar, int to bigint and float precision are allowed", err.Error())
+}
+
+func columnsByName(cols []scoop_protocol.ColumnDefinition) map[string]scoop_protocol.ColumnDefinition {
+	m := make(map[string]scoop_protocol.ColumnDefinition, len(cols))
+	for _, col := range cols {
+		m[col.OutboundName] = col
+	}
+	return m
+}
+
+func TestRestoreOperations(t *testing.T) {
+	require := require.New(t)
+	var rows []operationRow
+	rows = append(rows, operationRows(0,
+		scoop_protocol.NewAddOperation("time", "time", "f@timestamp@unix", "", ""),
+		scoop_protocol.NewAddOperation("backend", "backend", "varchar", "(32)", ""))...)
+	rows = append(rows, operationRows(1,
+		scoop_protocol.NewRenameOperation("backend", "video_backend"),
+		NewClassifyOperation("video_backend", core.PIIPseudonymous),
+		NewDeprecateOperation("video_backend", "use backend_id", "2000-01-01"))...)
+
+	_, err := restoreOperations(rows, "oops")
+	require.NotNil(err)
+
+	rows = append(rows, operationRows(2,
+		scoop_protocol.NewDropEventOperation("unused"))...)
+	ops, err := restoreOperations(rows, "oops")
+	require.Nil(err)
+	require.Len(ops, 4)
+	require.Equal(scoop_protocol.CANCEL_DROP_EVENT, ops[0].Action)
+	require.Equal("oops", ops[0].ActionMetadata["reason"])
+
+	schema := AnnotatedSchema{}
+	require.Nil(ApplyOperations(&schema, ops))
+	require.False(schema.Dropped)
+	require.Equal([]scoop_protocol.ColumnDefinition{
+		column("time", "f@timestamp@unix", "", ""),
+		{InboundName: "backend", OutboundName: "video_backend", Transformer: "varchar", ColumnCreationOptions: "(32)"},
+	}, schema.Columns)
+	require.Equal(map[string]string{"video_backend": core.PIIPseudonymous}, schema.PII)
+	require.Equal(map[string]ColumnDeprecation{
+		"video_backend": {Reason: "use backend_id", Sunset: "2000-01-01"},
+	}, schema.Deprecations)
+}
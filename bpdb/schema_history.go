@@ -0,0 +1,61 @@
+package bpdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// SchemaVersion is a single version of a schema, with the operations that
+// produced it and who made them.
+type SchemaVersion struct {
+	Version     int
+	TS          time.Time
+	UserName    string
+	Operations  []scoop_protocol.Operation
+	ColumnCount int
+}
+
+// SchemaHistory is a page of the versions of a schema, newest first.
+type SchemaHistory struct {
+	EventName     string
+	TotalVersions int
+	Offset        int
+	Limit         int
+	Versions      []SchemaVersion
+}
+
+// buildSchemaHistory replays the operation rows of a single event, recording
+// every version along the way. Rows must be ordered by version and ordering.
+// The versions are returned newest first.
+func buildSchemaHistory(rows []operationRow) ([]SchemaVersion, error) {
+	var versions []SchemaVersion
+	schema := &AnnotatedSchema{}
+	for i, row := range rows {
+		op := scoop_protocol.Operation{
+			Action:         scoop_protocol.Action(row.action),
+			ActionMetadata: row.actionMetadata,
+			Name:           row.name,
+			Version:        row.version,
+			Ordering:       row.ordering,
+		}
+		if err := ApplyOperation(schema, op); err != nil {
+			return nil, fmt.Errorf("applying operation of version %d: %v", row.version, err)
+		}
+		if i == 0 || rows[i-1].version != row.version {
+			versions = append(versions, SchemaVersion{
+				Version:  row.version,
+				TS:       row.ts,
+				UserName: row.userName,
+			})
+		}
+		current := &versions[len(versions)-1]
+		current.Operations = append(current.Operations, op)
+		current.ColumnCount = len(schema.Columns)
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	return versions, nil
+}
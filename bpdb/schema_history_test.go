@@ -0,0 +1,35 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestBuildSchemaHistory(t *testing.T) {
+	require := require.New(t)
+	var rows []operationRow
+	rows = append(rows, operationRows(0,
+		scoop_protocol.NewAddOperation("time", "time", "f@timestamp@unix", "", ""),
+		scoop_protocol.NewAddOperation("backend", "backend", "varchar", "(32)", ""))...)
+	rows = append(rows, operationRows(1,
+		scoop_protocol.NewDeleteOperation("backend"))...)
+	rows = append(rows, operationRows(2,
+		scoop_protocol.NewAddOperation("os", "os", "varchar", "(16)", ""),
+		scoop_protocol.NewAddOperation("quality", "quality", "varchar", "(16)", ""))...)
+	rows[len(rows)-1].userName = "other"
+
+	versions, err := buildSchemaHistory(rows)
+	require.Nil(err)
+	require.Len(versions, 3)
+	require.Equal(2, versions[0].Version)
+	require.Equal(3, versions[0].ColumnCount)
+	require.Len(versions[0].Operations, 2)
+	require.Equal(1, versions[1].Version)
+	require.Equal(1, versions[1].ColumnCount)
+	require.Equal(scoop_protocol.DELETE, versions[1].Operations[0].Action)
+	require.Equal("tester", versions[1].UserName)
+	require.Equal(0, versions[2].Version)
+	require.Equal(2, versions[2].ColumnCount)
+}
@@ -0,0 +1,67 @@
+package bpdb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// SchemaPreview is the result a schema create or update would have, had it
+// been stored.
+type SchemaPreview struct {
+	EventName  string
+	Version    int
+	Columns    []scoop_protocol.ColumnDefinition
+	Operations []scoop_protocol.Operation
+	Warnings   []string
+	Lint       []LintResult
+}
+
+// newSchemaPreview returns a preview of `schema` being stored at `version`
+// by the given operations.
+func newSchemaPreview(schema *AnnotatedSchema, ops []scoop_protocol.Operation, version int, warnings []string) *SchemaPreview {
+	preview := &SchemaPreview{
+		EventName:  schema.EventName,
+		Version:    version,
+		Columns:    schema.Columns,
+		Operations: make([]scoop_protocol.Operation, 0, len(ops)),
+		Warnings:   warnings,
+	}
+	for i, op := range ops {
+		op.Version = version
+		op.Ordering = i
+		preview.Operations = append(preview.Operations, op)
+	}
+	if preview.Warnings == nil {
+		preview.Warnings = []string{}
+	}
+	return preview
+}
+
+// updateWarnings returns warnings about the parts of a valid update that
+// affect existing data or queries.
+func updateWarnings(req *core.ClientUpdateSchemaRequest) []string {
+	var warnings []string
+	for _, name := range req.Deletes {
+		warnings = append(warnings, fmt.Sprintf("column %s will be deleted and its data will no longer be loaded", name))
+	}
+	for _, retype := range req.Retypes {
+		warnings = append(warnings, fmt.Sprintf("column %s will be altered in place to %s%s", retype.OutboundName, retype.Transformer, retype.Options))
+	}
+	for _, deprecation := range req.Deprecations {
+		warnings = append(warnings, fmt.Sprintf("column %s will be deprecated and can be deleted from %s",
+			deprecation.OutboundName, deprecation.Sunset))
+	}
+	oldNames := make([]string, 0, len(req.Renames))
+	for oldName := range req.Renames {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+	for _, oldName := range oldNames {
+		warnings = append(warnings, fmt.Sprintf("column %s will be renamed to %s, breaking queries that use the old name",
+			oldName, req.Renames[oldName]))
+	}
+	return warnings
+}
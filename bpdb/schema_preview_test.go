@@ -0,0 +1,46 @@
+package bpdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestNewSchemaPreview(t *testing.T) {
+	require := require.New(t)
+	req := &core.ClientUpdateSchemaRequest{
+		EventName: "test",
+		Additions: []core.Column{{InboundName: "os", OutboundName: "os", Transformer: "varchar", Options: core.ColumnOptions{Length: 16}}},
+		Deletes:   []string{"backend"},
+		Renames:   core.Renames{"quality": "video_quality"},
+	}
+	schema := &AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			varcharColumn("backend", 32, ""),
+			varcharColumn("quality", 16, ""),
+		},
+		Deprecations: map[string]ColumnDeprecation{"backend": {Reason: "unused", Sunset: "2000-01-01"}},
+	}
+	require.Equal("", preValidateUpdate(req, schema))
+	ops := schemaUpdateRequestToOps(req)
+	require.Nil(ApplyOperations(schema, ops))
+
+	preview := newSchemaPreview(schema, ops, 4, updateWarnings(req))
+	require.Equal(4, preview.Version)
+	require.Equal([]scoop_protocol.ColumnDefinition{
+		{InboundName: "quality", OutboundName: "video_quality", Transformer: "varchar", ColumnCreationOptions: "(16)"},
+		varcharColumn("os", 16, ""),
+	}, preview.Columns)
+	require.Len(preview.Operations, 3)
+	for i, op := range preview.Operations {
+		require.Equal(4, op.Version)
+		require.Equal(i, op.Ordering)
+	}
+	require.Equal([]string{
+		"column backend will be deleted and its data will no longer be loaded",
+		"column quality will be renamed to video_quality, breaking queries that use the old name",
+	}, preview.Warnings)
+}
@@ -0,0 +1,331 @@
+package bpdb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// snapshotFormat is the version of the AnnotatedSchema stored in snapshots.
+// Bump it when replaying operations fills in new fields, so that older
+// snapshots are treated as stale.
+const snapshotFormat = 3
+
+var (
+	latestSnapshotQuery = `
+SELECT event, format, schema
+FROM schema_snapshot
+WHERE event = $1
+ORDER BY version DESC
+LIMIT 1
+`
+	allLatestSnapshotsQuery = `
+SELECT DISTINCT ON (event) event, format, schema
+FROM schema_snapshot
+ORDER BY event ASC, version DESC
+`
+	operationEventCountQuery = `
+SELECT count(DISTINCT event)
+FROM operation
+`
+	insertSnapshotQuery = `INSERT INTO schema_snapshot
+(event, version, format, schema)
+VALUES ($1, $2, $3, $4)
+`
+	deleteSnapshotsFromVersionQuery = `DELETE FROM schema_snapshot
+WHERE event = $1
+AND version >= $2
+`
+	deleteEventSnapshotsQuery = `DELETE FROM schema_snapshot
+WHERE event = $1
+`
+)
+
+// schemaSnapshot is a stored snapshot of a schema.
+type schemaSnapshot struct {
+	Format int
+	Schema *AnnotatedSchema
+}
+
+// SnapshotDrift is a difference between the latest schema snapshot of an
+// event and the schema rebuilt from its operation log. LogVersion is nil if
+// the event has no operations and SnapshotVersion is nil if it has no snapshot.
+type SnapshotDrift struct {
+	EventName       string
+	LogVersion      *int
+	SnapshotVersion *int
+	Problem         string
+}
+
+type driftByEventName []SnapshotDrift
+
+func (d driftByEventName) Len() int           { return len(d) }
+func (d driftByEventName) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d driftByEventName) Less(i, j int) bool { return d[i].EventName < d[j].EventName }
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// latestSnapshot returns the latest snapshot of `eventName`, or nil if it has
+// none in the current format.
+func latestSnapshot(q queryer, eventName string) (*AnnotatedSchema, error) {
+	rows, err := q.Query(latestSnapshotQuery, eventName)
+	if err != nil {
+		return nil, fmt.Errorf("querying for latest snapshot of %s: %v", eventName, err)
+	}
+	snapshots, err := scanSnapshots(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 || snapshots[0].Format != snapshotFormat {
+		return nil, nil
+	}
+	return snapshots[0].Schema, nil
+}
+
+// nextSnapshot returns the schema of `eventName` after the operations just
+// stored at `version` at time `ts`. It applies them to the latest snapshot,
+// only replaying the whole operation log if that snapshot is missing, stale
+// or more than a version behind.
+func nextSnapshot(tx *sql.Tx, eventName string, ops []scoop_protocol.Operation, version int, ts time.Time, user string) (*AnnotatedSchema, error) {
+	schema, err := latestSnapshot(tx, eventName)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil || schema.Version < version-1 {
+		schema, err = replayEvent(tx, eventName)
+		if err != nil {
+			return nil, fmt.Errorf("replaying %s to snapshot it: %v", eventName, err)
+		}
+		return schema, nil
+	}
+	err = advanceSnapshot(schema, ops, version, ts, user)
+	if err != nil {
+		return nil, fmt.Errorf("applying operations to snapshot of %s: %v", eventName, err)
+	}
+	return schema, nil
+}
+
+// advanceSnapshot applies operations stored at `version` at time `ts` by
+// `user` to a snapshot, as replaying them from the log would.
+func advanceSnapshot(schema *AnnotatedSchema, ops []scoop_protocol.Operation, version int, ts time.Time, user string) error {
+	err := ApplyOperations(schema, ops)
+	if err != nil {
+		return err
+	}
+	schema.Version = version
+	schema.TS = ts
+	schema.UserName = user
+	return nil
+}
+
+// replayEvent rebuilds the schema of `eventName`, including if it was
+// dropped, from its operation log. It returns nil if the event has no operations.
+func replayEvent(q queryer, eventName string) (*AnnotatedSchema, error) {
+	rows, err := q.Query(schemaQuery, eventName)
+	if err != nil {
+		return nil, fmt.Errorf("querying for schema %s: %v", eventName, err)
+	}
+	ops, err := scanOperationRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := replaySchemas(ops)
+	if err != nil {
+		return nil, fmt.Errorf("generating schema from operations: %v", err)
+	}
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	return schemas[0], nil
+}
+
+// insertSnapshot stores the snapshot of a schema at its version. Does not commit.
+func insertSnapshot(tx *sql.Tx, schema *AnnotatedSchema) error {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("marshalling snapshot of %s: %v", schema.EventName, err)
+	}
+	_, err = tx.Exec(insertSnapshotQuery, schema.EventName, schema.Version, snapshotFormat, b)
+	if err != nil {
+		return fmt.Errorf("INSERTing snapshot of %s: %v", schema.EventName, err)
+	}
+	return nil
+}
+
+// scanSnapshots scans rows of event, format and schema snapshots.
+func scanSnapshots(rows *sql.Rows) ([]schemaSnapshot, error) {
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			logger.WithError(err).Error("closing rows in postgres backend scanSnapshots")
+		}
+	}()
+	var snapshots []schemaSnapshot
+	for rows.Next() {
+		var eventName string
+		var format int
+		var b []byte
+		err := rows.Scan(&eventName, &format, &b)
+		if err != nil {
+			return nil, fmt.Errorf("parsing snapshot row: %v", err)
+		}
+		var schema AnnotatedSchema
+		err = json.Unmarshal(b, &schema)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling snapshot of %s: %v", eventName, err)
+		}
+		snapshots = append(snapshots, schemaSnapshot{Format: format, Schema: &schema})
+	}
+	return snapshots, nil
+}
+
+// findSnapshotDrift compares the schemas rebuilt from the operation log to the
+// latest snapshot of each event, returning the drift sorted by event name.
+func findSnapshotDrift(rebuilt []*AnnotatedSchema, snapshots map[string]schemaSnapshot) ([]SnapshotDrift, error) {
+	drift := []SnapshotDrift{}
+	seen := make(map[string]bool, len(rebuilt))
+	for _, schema := range rebuilt {
+		seen[schema.EventName] = true
+		logVersion := schema.Version
+		snapshot, ok := snapshots[schema.EventName]
+		if !ok {
+			drift = append(drift, SnapshotDrift{
+				EventName:  schema.EventName,
+				LogVersion: &logVersion,
+				Problem:    "missing snapshot",
+			})
+			continue
+		}
+		snapshotVersion := snapshot.Schema.Version
+		problem := ""
+		switch {
+		case snapshot.Format != snapshotFormat:
+			problem = "snapshot format is outdated"
+		case snapshotVersion < logVersion:
+			problem = "snapshot is behind the operation log"
+		case snapshotVersion > logVersion:
+			problem = "snapshot is ahead of the operation log"
+		default:
+			expected, err := json.Marshal(schema)
+			if err != nil {
+				return nil, fmt.Errorf("marshalling rebuilt schema %s: %v", schema.EventName, err)
+			}
+			actual, err := json.Marshal(snapshot.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("marshalling snapshot of %s: %v", schema.EventName, err)
+			}
+			if !bytes.Equal(expected, actual) {
+				problem = "snapshot differs from the operation log"
+			}
+		}
+		if problem != "" {
+			drift = append(drift, SnapshotDrift{
+				EventName:       schema.EventName,
+				LogVersion:      &logVersion,
+				SnapshotVersion: &snapshotVersion,
+				Problem:         problem,
+			})
+		}
+	}
+	for name, snapshot := range snapshots {
+		if !seen[name] {
+			snapshotVersion := snapshot.Schema.Version
+			drift = append(drift, SnapshotDrift{
+				EventName:       name,
+				SnapshotVersion: &snapshotVersion,
+				Problem:         "snapshot has no operations",
+			})
+		}
+	}
+	sort.Sort(driftByEventName(drift))
+	return drift, nil
+}
+
+// snapshotDrift rebuilds every schema from the operation log and compares
+// them to the latest snapshots, returning the rebuilt schemas by event name
+// along with the drift.
+func (s *schemaBackend) snapshotDrift() (map[string]*AnnotatedSchema, []SnapshotDrift, error) {
+	rows, err := s.db.Query(allSchemasQuery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying for all schemas: %v", err)
+	}
+	ops, err := scanOperationRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	rebuilt, err := replaySchemas(ops)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating schemas from operations: %v", err)
+	}
+
+	rows, err = s.db.Query(allLatestSnapshotsQuery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying for latest snapshots: %v", err)
+	}
+	latest, err := scanSnapshots(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshots := make(map[string]schemaSnapshot, len(latest))
+	for _, snapshot := range latest {
+		snapshots[snapshot.Schema.EventName] = snapshot
+	}
+
+	drift, err := findSnapshotDrift(rebuilt, snapshots)
+	if err != nil {
+		return nil, nil, err
+	}
+	byName := make(map[string]*AnnotatedSchema, len(rebuilt))
+	for _, schema := range rebuilt {
+		byName[schema.EventName] = schema
+	}
+	return byName, drift, nil
+}
+
+// SnapshotDrift reports every event whose latest schema snapshot does not
+// match the schema rebuilt from its operation log.
+func (s *schemaBackend) SnapshotDrift() ([]SnapshotDrift, error) {
+	_, drift, err := s.snapshotDrift()
+	return drift, err
+}
+
+// RebuildSnapshots replaces the snapshot of every drifted event with the
+// schema rebuilt from its operation log, returning the drift it repaired.
+func (s *schemaBackend) RebuildSnapshots() ([]SnapshotDrift, error) {
+	rebuilt, drift, err := s.snapshotDrift()
+	if err != nil {
+		return nil, err
+	}
+	if len(drift) == 0 {
+		return drift, nil
+	}
+	return drift, execFnInTransaction(func(tx *sql.Tx) error {
+		for _, d := range drift {
+			if d.LogVersion == nil {
+				_, err := tx.Exec(deleteEventSnapshotsQuery, d.EventName)
+				if err != nil {
+					return fmt.Errorf("DELETEing snapshots of %s: %v", d.EventName, err)
+				}
+				continue
+			}
+			_, err := tx.Exec(deleteSnapshotsFromVersionQuery, d.EventName, *d.LogVersion)
+			if err != nil {
+				return fmt.Errorf("DELETEing snapshots of %s: %v", d.EventName, err)
+			}
+			err = insertSnapshot(tx, rebuilt[d.EventName])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, s.db)
+}
@@ -0,0 +1,72 @@
+package bpdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestFindSnapshotDrift(t *testing.T) {
+	require := require.New(t)
+	rebuilt := []*AnnotatedSchema{
+		{EventName: "current", Version: 2, Columns: []scoop_protocol.ColumnDefinition{varcharColumn("a", 8, "")}},
+		{EventName: "differs", Version: 2, Columns: []scoop_protocol.ColumnDefinition{varcharColumn("a", 8, "")}},
+		{EventName: "missing", Version: 0},
+		{EventName: "outdated", Version: 1},
+		{EventName: "stale", Version: 3},
+	}
+	snapshots := map[string]schemaSnapshot{
+		"current": {Format: snapshotFormat, Schema: &AnnotatedSchema{
+			EventName: "current", Version: 2, Columns: []scoop_protocol.ColumnDefinition{varcharColumn("a", 8, "")}}},
+		"differs": {Format: snapshotFormat, Schema: &AnnotatedSchema{
+			EventName: "differs", Version: 2, Columns: []scoop_protocol.ColumnDefinition{varcharColumn("a", 16, "")}}},
+		"orphan":   {Format: snapshotFormat, Schema: &AnnotatedSchema{EventName: "orphan", Version: 4}},
+		"outdated": {Format: snapshotFormat - 1, Schema: &AnnotatedSchema{EventName: "outdated", Version: 1}},
+		"stale":    {Format: snapshotFormat, Schema: &AnnotatedSchema{EventName: "stale", Version: 2}},
+	}
+
+	drift, err := findSnapshotDrift(rebuilt, snapshots)
+	require.Nil(err)
+	require.Len(drift, 5)
+	require.Equal("differs", drift[0].EventName)
+	require.Equal("snapshot differs from the operation log", drift[0].Problem)
+	require.Equal("missing", drift[1].EventName)
+	require.Nil(drift[1].SnapshotVersion)
+	require.Equal("orphan", drift[2].EventName)
+	require.Nil(drift[2].LogVersion)
+	require.Equal(4, *drift[2].SnapshotVersion)
+	require.Equal("outdated", drift[3].EventName)
+	require.Equal("snapshot format is outdated", drift[3].Problem)
+	require.Equal("stale", drift[4].EventName)
+	require.Equal(3, *drift[4].LogVersion)
+	require.Equal(2, *drift[4].SnapshotVersion)
+}
+
+func TestAdvanceSnapshot(t *testing.T) {
+	require := require.New(t)
+	created := operationRows(0,
+		scoop_protocol.NewAddOperation("time", "time", "f@timestamp@unix", "", ""),
+		scoop_protocol.NewAddOperation("backend", "backend", "varchar", "(32)", ""))
+	updates := []scoop_protocol.Operation{
+		scoop_protocol.NewRenameOperation("backend", "video_backend"),
+		NewDeprecateOperation("video_backend", "use backend_id", "2000-01-01"),
+	}
+	schemas, err := replaySchemas(created)
+	require.Nil(err)
+	b, err := json.Marshal(schemas[0])
+	require.Nil(err)
+	var snapshot AnnotatedSchema
+	require.Nil(json.Unmarshal(b, &snapshot))
+	require.Nil(advanceSnapshot(&snapshot, updates, 1, time.Time{}, "tester"))
+
+	replayed, err := replaySchemas(append(created, operationRows(1, updates...)...))
+	require.Nil(err)
+	expected, err := json.Marshal(replayed[0])
+	require.Nil(err)
+	actual, err := json.Marshal(&snapshot)
+	require.Nil(err)
+	require.JSONEq(string(expected), string(actual))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/twitchscience/blueprint/core"
@@ -109,6 +110,27 @@ func TestPreValidateSchemaBadType(t *testing.T) {
 	require.NotNil(t, preValidateSchema(&cfg), "Expected error on invalid type.")
 }
 
+func TestPreValidateSchemaBadColumnOptions(t *testing.T) {
+	require := require.New(t)
+	cfg := scoop_protocol.Config{
+		EventName: "name",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix", ColumnCreationOptions: " sortkey"},
+			{InboundName: "this", OutboundName: "that", Transformer: "bigint", ColumnCreationOptions: "varchar"},
+		},
+	}
+	require.Equal(`column options invalid for that: unknown option "varchar" in "varchar"`, preValidateSchema(&cfg).Error())
+
+	cfg.Columns[1].ColumnCreationOptions = "(32) distkey"
+	require.Equal("column options invalid for that: bigint does not take a length, given (32)", preValidateSchema(&cfg).Error())
+
+	cfg.Columns[1].ColumnCreationOptions = " distkey encode text255"
+	require.Equal("column options invalid for that: encoding text255 cannot be used with bigint", preValidateSchema(&cfg).Error())
+
+	cfg.Columns[1].ColumnCreationOptions = " distkey encode az64"
+	require.Nil(preValidateSchema(&cfg))
+}
+
 func TestPreValidateSchemaOkay(t *testing.T) {
 	cfg := scoop_protocol.Config{
 		EventName: "name",
@@ -304,8 +326,9 @@ func TestPreValidateUpdateAddDelete(t *testing.T) {
 		Renames:   core.Renames{},
 	}
 	schema := AnnotatedSchema{
-		EventName: "test",
-		Columns:   []scoop_protocol.ColumnDefinition{{OutboundName: "x"}},
+		EventName:    "test",
+		Columns:      []scoop_protocol.ColumnDefinition{{OutboundName: "x"}},
+		Deprecations: map[string]ColumnDeprecation{"x": {Reason: "retyped", Sunset: "2000-01-01"}},
 	}
 	requestErr := preValidateUpdate(&req, &schema)
 	require.Equal(t, requestErr, "")
@@ -331,6 +354,19 @@ func TestPreValidateUpdateAddErrors(t *testing.T) {
 	require.Equal(requestErr[:26], "Column transformer invalid")
 
 	req.Additions[0].Transformer = "bool"
+	req.Additions[0].Options = core.ColumnOptionsFromString("(abc)")
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, `Column options invalid for x: length must be a positive integer, given "(abc)"`)
+
+	req.Additions[0].Options = core.ColumnOptions{Length: 32}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Column options invalid for x: bool does not take a length, given (32)")
+
+	req.Additions[0].Options = core.ColumnOptions{DistKey: true}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Keys can only be set when creating a schema, cannot add key column: x")
+
+	req.Additions[0].Options = core.ColumnOptions{}
 	requestErr = preValidateUpdate(&req, &schema)
 	require.Equal(requestErr, "")
 
@@ -377,6 +413,207 @@ func TestPreValidateUpdateRenameErrors(t *testing.T) {
 	require.Equal(requestErr, "Attempting to rename to duplicate column: x")
 }
 
+func TestPreValidateUpdateRetypeErrors(t *testing.T) {
+	require := require.New(t)
+	req := core.ClientUpdateSchemaRequest{
+		EventName: "test",
+		Additions: []core.Column{},
+		Deletes:   []string{},
+		Renames:   core.Renames{},
+		Retypes:   []core.Retype{{OutboundName: "a", Transformer: "varchar", Options: core.ColumnOptions{Length: 64}}},
+	}
+	schema := AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "x", Transformer: "varchar", ColumnCreationOptions: "(32)"},
+			{OutboundName: "y", Transformer: "int"},
+			{OutboundName: "z", Transformer: "float", ColumnCreationOptions: "(24)"},
+			{OutboundName: "k", Transformer: "varchar", ColumnCreationOptions: "(32) sortkey"},
+			{OutboundName: "e", Transformer: "varchar", ColumnCreationOptions: "(64) encode zstd"},
+		},
+	}
+	requestErr := preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Attempting to retype column that doesn't exist: a")
+
+	req.Retypes = []core.Retype{{OutboundName: "e", Transformer: "varchar", Options: core.ColumnOptions{Length: 128, Encoding: "zstd"}}}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "")
+
+	req.Retypes = []core.Retype{{OutboundName: "e", Transformer: "varchar", Options: core.ColumnOptions{Length: 128}}}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, `Cannot retype column e: encoding cannot be changed, given "zstd" to ""`)
+
+	req.Retypes = []core.Retype{{OutboundName: "k", Transformer: "varchar", Options: core.ColumnOptions{Length: 64}}}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Column is a key and cannot be retyped: k")
+
+	req.Retypes = []core.Retype{{OutboundName: "x", Transformer: "varchar", Options: core.ColumnOptions{Length: 16}}}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Cannot retype column x: varchar length can only be increased, given 32 to 16")
+
+	req.Retypes = []core.Retype{{OutboundName: "x", Transformer: "bigint"}}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr[:36], "Cannot retype column x: cannot chang")
+
+	req.Retypes = []core.Retype{
+		{OutboundName: "x", Transformer: "varchar", Options: core.ColumnOptions{Length: 64}},
+		{OutboundName: "y", Transformer: "bigint"},
+		{OutboundName: "z", Transformer: "float"},
+	}
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "")
+
+	req.Retypes = append(req.Retypes, core.Retype{OutboundName: "x", Transformer: "varchar", Options: core.ColumnOptions{Length: 128}})
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Attempting to retype column more than once: x")
+
+	req.Retypes = req.Retypes[:1]
+	req.Deletes = []string{"x"}
+	req.ForceDelete = true
+	requestErr = preValidateUpdate(&req, &schema)
+	require.Equal(requestErr, "Attempting to retype column that doesn't exist: x")
+}
+
+func TestPreValidateUpdatePII(t *testing.T) {
+	require := require.New(t)
+	schema := AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "time", InboundName: "time", Transformer: "f@timestamp@unix"},
+			{OutboundName: "user_id", Transformer: "userIDWithMapping"},
+			{OutboundName: "email", Transformer: "varchar", ColumnCreationOptions: "(64)"},
+		},
+	}
+	req := core.ClientUpdateSchemaRequest{
+		EventName:       "test",
+		Classifications: map[string]string{"missing": core.PIINone},
+	}
+	require.Equal("Attempting to classify column that doesn't exist: missing", preValidateUpdate(&req, &schema))
+
+	req.Classifications = map[string]string{"user_id": core.PIINone}
+	require.Equal("PII class invalid for user_id: userIDWithMapping columns hold personal data and cannot be classified none",
+		preValidateUpdate(&req, &schema))
+
+	req.Classifications = map[string]string{"email": core.PIIDirectIdentifier}
+	require.Equal("", preValidateUpdate(&req, &schema))
+
+	req.Classifications = nil
+	req.Additions = []core.Column{{InboundName: "ip", OutboundName: "city", Transformer: "ipCity", PII: "secret"}}
+	require.Equal("PII class invalid for city: unknown PII class secret, expected one of none, pseudonymous, direct_identifier, location",
+		preValidateUpdate(&req, &schema))
+
+	req.Additions[0].PII = core.PIILocation
+	require.Equal("", preValidateUpdate(&req, &schema))
+}
+
+func TestValidateCreatePII(t *testing.T) {
+	require := require.New(t)
+	req := core.ClientCreateSchemaRequest{
+		Config: scoop_protocol.Config{
+			EventName: "test",
+			Columns: []scoop_protocol.ColumnDefinition{
+				{OutboundName: "time", InboundName: "time", Transformer: "f@timestamp@unix"},
+				{OutboundName: "city", InboundName: "ip", Transformer: "ipCity"},
+			},
+		},
+		PII: map[string]string{"email": core.PIIDirectIdentifier},
+	}
+	require.NotNil(validateCreatePII(&req))
+
+	req.PII = map[string]string{"city": core.PIINone}
+	require.NotNil(validateCreatePII(&req))
+
+	req.PII = map[string]string{"city": core.PIIDirectIdentifier}
+	require.Nil(validateCreatePII(&req))
+	ops := schemaCreateRequestToOps(&req.Config, req.PII)
+	require.Equal(core.PIINone, ops[0].ActionMetadata["pii"])
+	require.Equal(core.PIIDirectIdentifier, ops[1].ActionMetadata["pii"])
+}
+
+func TestPreValidateUpdateDeprecations(t *testing.T) {
+	require := require.New(t)
+	tomorrow := time.Now().UTC().Add(24 * time.Hour).Format(core.SunsetFormat)
+	req := core.ClientUpdateSchemaRequest{
+		EventName:    "test",
+		Deprecations: []core.Deprecation{{OutboundName: "a", Reason: "unused", Sunset: tomorrow}},
+	}
+	schema := AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "time", InboundName: "time", Transformer: "f@timestamp@unix"},
+			{OutboundName: "x", Transformer: "bigint"},
+			{OutboundName: "y", Transformer: "bigint"},
+		},
+	}
+	require.Equal("Attempting to deprecate column that doesn't exist: a", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = []core.Deprecation{{OutboundName: "time", Reason: "unused", Sunset: tomorrow}}
+	require.Equal("Cannot deprecate time column.", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = []core.Deprecation{{OutboundName: "x", Sunset: tomorrow}}
+	require.Equal("Deprecation of x must give a reason", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = []core.Deprecation{{OutboundName: "x", Reason: "unused", Sunset: "next week"}}
+	require.Equal(`Sunset of x must be a date like 2006-01-02, given "next week"`, preValidateUpdate(&req, &schema))
+
+	req.Deprecations = []core.Deprecation{{OutboundName: "x", Reason: "unused", Sunset: "2000-01-01"}}
+	require.Equal("Sunset of x cannot be in the past: 2000-01-01", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = []core.Deprecation{
+		{OutboundName: "x", Reason: "unused", Sunset: tomorrow},
+		{OutboundName: "x", Reason: "unused", Sunset: tomorrow},
+	}
+	require.Equal("Attempting to deprecate column more than once: x", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = req.Deprecations[:1]
+	require.Equal("", preValidateUpdate(&req, &schema))
+
+	req.Deprecations = nil
+	req.Deletes = []string{"x"}
+	require.Equal("Column must be deprecated before it can be deleted: x", preValidateUpdate(&req, &schema))
+
+	schema.Deprecations = map[string]ColumnDeprecation{"x": {Reason: "unused", Sunset: tomorrow}}
+	require.Equal("Column x cannot be deleted before its sunset on "+tomorrow, preValidateUpdate(&req, &schema))
+
+	schema.Deprecations["x"] = ColumnDeprecation{Reason: "unused", Sunset: "2000-01-01"}
+	require.Equal("", preValidateUpdate(&req, &schema))
+
+	req.Deletes = []string{"y"}
+	req.ForceDelete = true
+	require.Equal("", preValidateUpdate(&req, &schema))
+}
+
+func TestCloneConfig(t *testing.T) {
+	require := require.New(t)
+	source := &AnnotatedSchema{
+		EventName: "test",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "time", InboundName: "time", Transformer: "f@timestamp@unix"},
+			{OutboundName: "x", InboundName: "x", Transformer: "varchar", ColumnCreationOptions: "(32)"},
+			{OutboundName: "y", InboundName: "y", Transformer: "int"},
+		},
+	}
+	req := &core.ClientCloneSchemaRequest{SourceEventName: "test", EventName: "test_v2"}
+	cfg, err := CloneConfig(source, req)
+	require.Nil(err)
+	require.Equal("test_v2", cfg.EventName)
+	require.Equal(source.Columns, cfg.Columns)
+
+	req.Exclude = []string{"y"}
+	cfg, err = CloneConfig(source, req)
+	require.Nil(err)
+	require.Equal(source.Columns[:2], cfg.Columns)
+
+	req.Include = []string{"time", "y"}
+	cfg, err = CloneConfig(source, req)
+	require.Nil(err)
+	require.Equal(source.Columns[:1], cfg.Columns)
+
+	req.Include = []string{"z"}
+	_, err = CloneConfig(source, req)
+	require.Equal("included column z does not exist in test", err.Error())
+}
+
 func TestValidateKinesisConfigInvalidStreamName(t *testing.T) {
 	require := require.New(t)
 	var config scoop_protocol.KinesisWriterConfig
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Limits on the length in column options, and the lengths Redshift uses
+// when none is given.
+const (
+	MaxVarcharLength     = 65535
+	MaxFloatPrecision    = 53
+	DefaultVarcharLength = 256
+)
+
+// columnEncodings are the Redshift compression encodings, mapped to the
+// transformers they can be used with. A nil list means any transformer.
+var columnEncodings = map[string][]string{
+	"raw":       nil,
+	"zstd":      nil,
+	"lzo":       nil,
+	"runlength": nil,
+	"bytedict":  {"bigint", "float", "int", "ipAsn", "ipAsnInteger", "ipCity", "ipCountry", "ipRegion", "varchar", "f@timestamp@unix", "f@timestamp@unix-utc", "userIDWithMapping"},
+	"az64":      {"bigint", "int", "ipAsnInteger", "f@timestamp@unix", "f@timestamp@unix-utc", "userIDWithMapping"},
+	"delta":     {"bigint", "int", "ipAsnInteger", "f@timestamp@unix", "f@timestamp@unix-utc", "userIDWithMapping"},
+	"delta32k":  {"bigint", "int", "ipAsnInteger", "f@timestamp@unix", "f@timestamp@unix-utc", "userIDWithMapping"},
+	"mostly8":   {"bigint", "int", "ipAsnInteger", "userIDWithMapping"},
+	"mostly16":  {"bigint", "int", "ipAsnInteger", "userIDWithMapping"},
+	"mostly32":  {"bigint", "userIDWithMapping"},
+	"text255":   {"ipAsn", "ipCity", "ipCountry", "ipRegion", "varchar"},
+	"text32k":   {"ipAsn", "ipCity", "ipCountry", "ipRegion", "varchar"},
+}
+
+// ColumnOptions are the creation options of a column: its length and how
+// Redshift distributes, sorts and compresses it. On the wire and in the
+// operation log they are the string Redshift expects after the column type,
+// e.g. "(32) distkey encode zstd".
+type ColumnOptions struct {
+	// Length is the varchar length or float precision, 0 if not given.
+	Length   int
+	DistKey  bool
+	SortKey  bool
+	Encoding string
+
+	// raw and err hold the original string and why it could not be parsed,
+	// so that invalid options are reported by Validate rather than lost.
+	raw string
+	err error
+}
+
+// ParseColumnOptions parses column options from their string form.
+func ParseColumnOptions(s string) (ColumnOptions, error) {
+	var opts ColumnOptions
+	rest := strings.TrimSpace(s)
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return ColumnOptions{}, fmt.Errorf("unclosed length in %q", s)
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(rest[1:end]))
+		if err != nil || length < 1 {
+			return ColumnOptions{}, fmt.Errorf("length must be a positive integer, given %q", rest[:end+1])
+		}
+		opts.Length = length
+		rest = rest[end+1:]
+	}
+	words := strings.Fields(strings.ToLower(rest))
+	for i := 0; i < len(words); i++ {
+		switch words[i] {
+		case "distkey":
+			if opts.DistKey {
+				return ColumnOptions{}, fmt.Errorf("distkey given more than once in %q", s)
+			}
+			opts.DistKey = true
+		case "sortkey":
+			if opts.SortKey {
+				return ColumnOptions{}, fmt.Errorf("sortkey given more than once in %q", s)
+			}
+			opts.SortKey = true
+		case "encode":
+			if opts.Encoding != "" {
+				return ColumnOptions{}, fmt.Errorf("encode given more than once in %q", s)
+			}
+			if i+1 == len(words) {
+				return ColumnOptions{}, fmt.Errorf("encode must be followed by an encoding in %q", s)
+			}
+			i++
+			if _, ok := columnEncodings[words[i]]; !ok {
+				return ColumnOptions{}, fmt.Errorf("unknown encoding %s", words[i])
+			}
+			opts.Encoding = words[i]
+		default:
+			return ColumnOptions{}, fmt.Errorf("unknown option %q in %q", words[i], s)
+		}
+	}
+	return opts, nil
+}
+
+// ColumnOptionsFromString parses column options, keeping the string and the
+// error if they are invalid so that Validate reports it and String returns
+// the string unchanged.
+func ColumnOptionsFromString(s string) ColumnOptions {
+	opts, err := ParseColumnOptions(s)
+	if err != nil {
+		return ColumnOptions{raw: s, err: err}
+	}
+	return opts
+}
+
+// String returns the options in the form stored in column_options metadata.
+func (o ColumnOptions) String() string {
+	if o.err != nil {
+		return o.raw
+	}
+	var b bytes.Buffer
+	if o.Length > 0 {
+		fmt.Fprintf(&b, "(%d)", o.Length)
+	}
+	if o.DistKey {
+		b.WriteString(" distkey")
+	}
+	if o.SortKey {
+		b.WriteString(" sortkey")
+	}
+	if o.Encoding != "" {
+		fmt.Fprintf(&b, " encode %s", o.Encoding)
+	}
+	return b.String()
+}
+
+// VarcharLength returns the length of a varchar column with these options,
+// defaulting to Redshift's default length if none is given.
+func (o ColumnOptions) VarcharLength() int {
+	if o.Length > 0 {
+		return o.Length
+	}
+	return DefaultVarcharLength
+}
+
+// FloatPrecision returns the precision of a float column with these options,
+// defaulting to double precision if none is given.
+func (o ColumnOptions) FloatPrecision() int {
+	if o.Length > 0 {
+		return o.Length
+	}
+	return MaxFloatPrecision
+}
+
+// Validate returns an error if the options could not be parsed or do not
+// apply to a column with the given transformer.
+func (o ColumnOptions) Validate(transformer string) error {
+	if o.err != nil {
+		return o.err
+	}
+	switch transformer {
+	case "varchar":
+		if o.Length > MaxVarcharLength {
+			return fmt.Errorf("varchar length can be at most %d, given %d", MaxVarcharLength, o.Length)
+		}
+	case "float":
+		if o.Length > MaxFloatPrecision {
+			return fmt.Errorf("float precision can be at most %d, given %d", MaxFloatPrecision, o.Length)
+		}
+	default:
+		if o.Length > 0 {
+			return fmt.Errorf("%s does not take a length, given (%d)", transformer, o.Length)
+		}
+	}
+	if o.Encoding != "" {
+		allowed := columnEncodings[o.Encoding]
+		if allowed != nil && !stringInList(transformer, allowed) {
+			return fmt.Errorf("encoding %s cannot be used with %s", o.Encoding, transformer)
+		}
+	}
+	return nil
+}
+
+func stringInList(needle string, haystack []string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON writes the options as their string form.
+func (o ColumnOptions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.String())
+}
+
+// UnmarshalJSON reads the options from their string form. Invalid options
+// are kept and reported by Validate, so clients get a validation error
+// rather than a decoding one.
+func (o *ColumnOptions) UnmarshalJSON(b []byte) error {
+	var s *string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return fmt.Errorf("column options must be a string: %v", err)
+	}
+	if s == nil {
+		*o = ColumnOptions{}
+		return nil
+	}
+	*o = ColumnOptionsFromString(*s)
+	return nil
+}
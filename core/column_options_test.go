@@ -0,0 +1,40 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumnOptions(t *testing.T) {
+	require := require.New(t)
+	opts, err := ParseColumnOptions(" (32)  DISTKEY encode zstd ")
+	require.Nil(err)
+	require.Equal(ColumnOptions{Length: 32, DistKey: true, Encoding: "zstd"}, opts)
+	require.Equal("(32) distkey encode zstd", opts.String())
+
+	opts, err = ParseColumnOptions(" sortkey")
+	require.Nil(err)
+	require.Equal(" sortkey", opts.String())
+
+	for _, invalid := range []string{"(abc)", "(0)", "(32", "varchar", "distkey distkey", "encode", "encode gzip"} {
+		_, err = ParseColumnOptions(invalid)
+		require.NotNil(err, invalid)
+	}
+}
+
+func TestColumnOptionsJSON(t *testing.T) {
+	require := require.New(t)
+	var col Column
+	require.Nil(json.Unmarshal([]byte(`{"OutboundName": "x", "ColumnCreationOptions": "(abc)"}`), &col))
+	require.NotNil(col.Options.Validate("varchar"))
+	b, err := json.Marshal(col.Options)
+	require.Nil(err)
+	require.Equal(`"(abc)"`, string(b))
+
+	require.Nil(json.Unmarshal([]byte(`{"ColumnCreationOptions": "(64) sortkey"}`), &col))
+	require.Equal(ColumnOptions{Length: 64, SortKey: true}, col.Options)
+	require.Nil(col.Options.Validate("varchar"))
+	require.NotNil(col.Options.Validate("float"))
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
@@ -64,25 +65,103 @@ type Column struct {
 	// Transformer is the column's SQL type.
 	Transformer string `json:"Transformer"`
 
-	// Length is the length of the SQL type, e.g. for a variable type like varchar.
-	// TODO: length should be an int, currently the client supplies this
-	// to us, so pass through now, with a view to fixing this later
-	Length string `json:"ColumnCreationOptions"`
+	// Options are the column's length, keys and encoding.
+	Options ColumnOptions `json:"ColumnCreationOptions"`
 
 	// SupportingColumns are the names of extra columns required to map a value to this column
 	SupportingColumns string `json:"SupportingColumns"`
+
+	// PII is the column's PII class, defaulting to DefaultPIIClass of its transformer.
+	PII string `json:"PII"`
+}
+
+// ClientCreateSchemaRequest is a request to create the schema for an event.
+type ClientCreateSchemaRequest struct {
+	scoop_protocol.Config
+
+	// PII maps outbound column names to their PII class. Columns not given
+	// default to DefaultPIIClass of their transformer.
+	PII map[string]string
+
+	// OwnerTeam is the team that will own the event, stored as its
+	// owner_team metadata. The event name must have one of its prefixes.
+	OwnerTeam string
 }
 
 // Renames is a map of old name to new name, representing a rename operation on
 // a set of columns.
 type Renames map[string]string
 
+// Retype is a change to the type of an existing column. Only widenings that can
+// be applied in place, without rewriting the column's data, are allowed.
+type Retype struct {
+	// OutboundName is the name of the column to change, before any renames in the same request.
+	OutboundName string `json:"OutboundName"`
+
+	// Transformer is the column's new SQL type.
+	Transformer string `json:"Transformer"`
+
+	// Options are the column's new options. Only the length may change.
+	Options ColumnOptions `json:"ColumnCreationOptions"`
+}
+
+// SunsetFormat is the layout of deprecation sunset dates.
+const SunsetFormat = "2006-01-02"
+
+// Deprecation marks a column as due to be deleted. Columns can only be deleted
+// once they have been deprecated and their sunset has passed.
+type Deprecation struct {
+	// OutboundName is the name of the column to deprecate, before any renames in the same request.
+	OutboundName string `json:"OutboundName"`
+
+	// Reason tells the column's users why it is going away and what to use instead.
+	Reason string `json:"Reason"`
+
+	// Sunset is the date, in SunsetFormat, from which the column may be deleted.
+	Sunset string `json:"Sunset"`
+}
+
 // ClientUpdateSchemaRequest is a request to update the schema for an event.
 type ClientUpdateSchemaRequest struct {
-	EventName string `json:"-"`
-	Additions []Column
-	Deletes   []string
-	Renames   Renames
+	EventName    string `json:"-"`
+	Additions    []Column
+	Deletes      []string
+	Renames      Renames
+	Retypes      []Retype
+	Deprecations []Deprecation
+
+	// Classifications maps existing outbound column names, before any renames
+	// in the same request, to their new PII class.
+	Classifications map[string]string
+
+	// BaseVersion is the version of the schema the update was made against.
+	// If set and the schema has moved on since, the update is rejected with a
+	// VersionConflict.
+	BaseVersion *int
+
+	// ForceDelete allows deleting columns that have not been deprecated past
+	// their sunset. It is only set by the admin API.
+	ForceDelete bool `json:"-"`
+}
+
+// ClientBulkUpdateSchemaEntry is the update to one event in a bulk update.
+type ClientBulkUpdateSchemaEntry struct {
+	EventName string
+	ClientUpdateSchemaRequest
+}
+
+// VersionConflict describes an update that was made against an out of date
+// version of a schema, along with the operations made since that version.
+type VersionConflict struct {
+	EventName      string
+	BaseVersion    int
+	CurrentVersion int
+	Operations     []*scoop_protocol.Operation
+}
+
+func (vc *VersionConflict) Error() string {
+	return fmt.Sprintf("schema %s was updated from version %d to %d since this change was made",
+		vc.EventName, vc.BaseVersion, vc.CurrentVersion)
 }
 
 // ClientDropSchemaRequest is a request to drop the schema for an event.
@@ -91,6 +170,27 @@ type ClientDropSchemaRequest struct {
 	Reason    string
 }
 
+// ClientCloneSchemaRequest is a request to create a new event from the columns
+// of an existing one. If Include is given only those columns are cloned, and
+// columns in Exclude are never cloned.
+type ClientCloneSchemaRequest struct {
+	SourceEventName string `json:"-"`
+	EventName       string
+	Include         []string
+	Exclude         []string
+
+	// OwnerTeam is the team that will own the new event, as in
+	// ClientCreateSchemaRequest.
+	OwnerTeam string
+}
+
+// ClientRestoreSchemaRequest is a request to restore a dropped schema with the
+// columns it had before it was dropped.
+type ClientRestoreSchemaRequest struct {
+	EventName string
+	Reason    string
+}
+
 // ClientUpdateEventCommentRequest is a request to update the comment for an event.
 type ClientUpdateEventCommentRequest struct {
 	EventName    string
@@ -104,15 +204,51 @@ type ClientUpdateEventMetadataRequest struct {
 	MetadataValue string
 }
 
-// WebError is either a server or user error.
+// ClientRevertEventMetadataRequest is a request to restore the value an
+// event's metadata had at an earlier version.
+type ClientRevertEventMetadataRequest struct {
+	EventName    string `json:"-"`
+	MetadataType scoop_protocol.EventMetadataType
+	Version      int
+}
+
+// ClientUpdateColumnDocRequest is a request to update the doc of a column.
+type ClientUpdateColumnDocRequest struct {
+	EventName   string `json:"-"`
+	Column      string `json:"-"`
+	Description string
+	Examples    []string
+	Owner       string
+	Units       string
+}
+
+// WebError is either a server error, a user error or a version conflict.
 type WebError struct {
-	ServerError error
-	UserError   error
+	ServerError   error
+	UserError     error
+	ConflictError *VersionConflict
 }
 
 // ReportError reports the WebError's error and the given message to the ResponseWriter/logger.
+// Version conflicts are reported as JSON, so that clients can show what changed.
 func (we WebError) ReportError(w http.ResponseWriter, message string) {
-	if we.ServerError != nil {
+	if we.ConflictError != nil {
+		logger.WithError(we.ConflictError).Info(message)
+		js, err := json.Marshal(struct {
+			Message string
+			*VersionConflict
+		}{message + ": " + we.ConflictError.Error(), we.ConflictError})
+		if err != nil {
+			logger.WithError(err).Error("Failed to marshal version conflict")
+			http.Error(w, "Internal error: "+message, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		if _, err := w.Write(js); err != nil {
+			logger.WithError(err).Error("Failed to write version conflict")
+		}
+	} else if we.ServerError != nil {
 		logger.WithError(we.ServerError).Error(message)
 		http.Error(w, "Internal error: "+message, http.StatusInternalServerError)
 	} else if we.UserError != nil {
@@ -147,9 +283,20 @@ func NewUserWebErrorf(format string, a ...interface{}) *WebError {
 	return &WebError{UserError: fmt.Errorf(format, a...)}
 }
 
+// NewConflictWebError returns a WebError representing a version conflict.
+func NewConflictWebError(conflict *VersionConflict) *WebError {
+	if conflict == nil {
+		return nil
+	}
+	return &WebError{ConflictError: conflict}
+}
+
 // AnnotateWebError adds a prefix to the error string for the web error, colon
-// delimited
+// delimited. Version conflicts are passed through unchanged.
 func AnnotateWebError(msg string, err *WebError) *WebError {
+	if err.ConflictError != nil {
+		return err
+	}
 	if err.UserError != nil {
 		return &WebError{UserError: fmt.Errorf(msg+": %v", err.UserError)}
 	}
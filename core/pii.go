@@ -0,0 +1,41 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PII classes of columns, from what the data they hold reveals about a user.
+const (
+	PIINone             = "none"
+	PIIPseudonymous     = "pseudonymous"
+	PIIDirectIdentifier = "direct_identifier"
+	PIILocation         = "location"
+)
+
+var piiClasses = []string{PIINone, PIIPseudonymous, PIIDirectIdentifier, PIILocation}
+
+// DefaultPIIClass returns the class of a column with the given transformer
+// when none is given: user IDs are pseudonymous and anything derived from an
+// IP address is a location.
+func DefaultPIIClass(transformer string) string {
+	switch {
+	case transformer == "userIDWithMapping":
+		return PIIPseudonymous
+	case strings.HasPrefix(transformer, "ip"):
+		return PIILocation
+	}
+	return PIINone
+}
+
+// ValidatePIIClass returns an error if the class is unknown, or if it marks a
+// column whose transformer always produces personal data as not sensitive.
+func ValidatePIIClass(class, transformer string) error {
+	if !stringInList(class, piiClasses) {
+		return fmt.Errorf("unknown PII class %s, expected one of %s", class, strings.Join(piiClasses, ", "))
+	}
+	if class == PIINone && DefaultPIIClass(transformer) != PIINone {
+		return fmt.Errorf("%s columns hold personal data and cannot be classified %s", transformer, PIINone)
+	}
+	return nil
+}
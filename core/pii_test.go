@@ -0,0 +1,20 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIIClass(t *testing.T) {
+	require := require.New(t)
+	require.Equal(PIIPseudonymous, DefaultPIIClass("userIDWithMapping"))
+	require.Equal(PIILocation, DefaultPIIClass("ipCity"))
+	require.Equal(PIINone, DefaultPIIClass("varchar"))
+
+	require.Nil(ValidatePIIClass(PIIDirectIdentifier, "varchar"))
+	require.Nil(ValidatePIIClass(PIINone, "varchar"))
+	require.Nil(ValidatePIIClass(PIIDirectIdentifier, "userIDWithMapping"))
+	require.NotNil(ValidatePIIClass("secret", "varchar"))
+	require.NotNil(ValidatePIIClass(PIINone, "ipAsn"))
+}
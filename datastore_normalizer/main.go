@@ -0,0 +1,110 @@
+/*
+Command datastore_normalizer rewrites the DATASTORES metadata of every event as
+a normalized set of datastores from the registry in bpdb. Each rewrite is
+stored as a new version of the metadata, so it can be reverted. Values that
+cannot be mapped unambiguously, such as datastores missing from the registry
+or Kinesis streams without a Kinesis config, are left as they are and listed
+at the end for a person to fix.
+*/
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"sort"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+var (
+	bpdbConnection = flag.String("bpdbConnection", "", "The connection string for blueprintdb")
+	user           = flag.String("user", "datastore_normalizer", "The user to record the rewrites as")
+	dryRun         = flag.Bool("dryRun", true, "Only log the rewrites, without storing them")
+)
+
+// unmapped is a DATASTORES value the normalizer could not map to the registry.
+type unmapped struct {
+	event string
+	value string
+	err   error
+}
+
+func main() {
+	flag.Parse()
+	logger.Init("info")
+
+	db, err := sql.Open("postgres", *bpdbConnection)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to DB")
+	}
+	schemaBackend, err := bpdb.NewSchemaBackend(db)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to set up schema backend")
+	}
+	kinesisConfigs, err := bpdb.NewKinesisConfigBackend(db, nil).AllKinesisConfigs()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to get Kinesis configs")
+	}
+	allMetadata, err := schemaBackend.AllEventMetadata()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to get event metadata")
+	}
+
+	events := make([]string, 0, len(allMetadata.Metadata))
+	for event := range allMetadata.Metadata {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	var rewritten, failed int
+	var toFix []unmapped
+	for _, event := range events {
+		row, ok := allMetadata.Metadata[event][string(scoop_protocol.DATASTORES)]
+		if !ok {
+			continue
+		}
+		log := logger.WithField("event", event).WithField("value", row.MetadataValue)
+		normalized, err := bpdb.NormalizeDatastores(row.MetadataValue)
+		if err == nil {
+			err = bpdb.ValidateDatastoreStreams(normalized, kinesisConfigs)
+		}
+		if err != nil {
+			toFix = append(toFix, unmapped{event, row.MetadataValue, err})
+			continue
+		}
+		if normalized == row.MetadataValue {
+			continue
+		}
+		log.WithField("normalized", normalized).Info("Normalizing datastores")
+		rewritten++
+		if *dryRun {
+			continue
+		}
+		webErr := schemaBackend.UpdateEventMetadata(&core.ClientUpdateEventMetadataRequest{
+			EventName:     event,
+			MetadataType:  scoop_protocol.DATASTORES,
+			MetadataValue: normalized,
+		}, *user)
+		if webErr != nil {
+			err = webErr.ServerError
+			if err == nil {
+				err = webErr.UserError
+			}
+			log.WithError(err).Error("Failed to store normalized datastores")
+			failed++
+		}
+	}
+	for _, u := range toFix {
+		logger.WithField("event", u.event).
+			WithField("value", u.value).
+			WithError(u.err).
+			Warn("Cannot map datastores unambiguously, fix them by hand")
+	}
+	logger.WithField("rewritten", rewritten).
+		WithField("to_fix", len(toFix)).
+		WithField("failed", failed).
+		WithField("dry_run", *dryRun).
+		Info("Done normalizing datastores")
+}
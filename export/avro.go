@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// AvroNamespace is the namespace of the generated Avro records.
+const AvroNamespace = "blueprint"
+
+var invalidNameRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// AvroLogicalType is an Avro primitive type annotated with a logical type.
+type AvroLogicalType struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+}
+
+// AvroField is a field of an Avro record. Every field is a union with null
+// that defaults to null, since producers may omit any property.
+type AvroField struct {
+	Name    string        `json:"name"`
+	Type    []interface{} `json:"type"`
+	Default interface{}   `json:"default"`
+}
+
+// AvroSchema is an Avro record schema describing the rows of an event.
+type AvroSchema struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Doc       string      `json:"doc"`
+	Fields    []AvroField `json:"fields"`
+}
+
+// safeName replaces the characters Avro does not allow in names with
+// underscores, so that event names like minute-watched are valid record and
+// message names in both Avro and Parquet.
+func safeName(name string) string {
+	name = invalidNameRe.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// AvroType returns the Avro type of the column, not including the null union.
+func AvroType(col scoop_protocol.ColumnDefinition) (interface{}, error) {
+	switch col.Transformer {
+	case "bigint", "userIDWithMapping":
+		return "long", nil
+	case "int", "ipAsnInteger":
+		return "int", nil
+	case "bool":
+		return "boolean", nil
+	case "float":
+		if core.ColumnOptionsFromString(col.ColumnCreationOptions).FloatPrecision() <= floatMaxPrecision {
+			return "float", nil
+		}
+		return "double", nil
+	case "varchar", "ipAsn", "ipCity", "ipCountry", "ipRegion":
+		return "string", nil
+	case "f@timestamp@unix", "f@timestamp@unix-utc":
+		return AvroLogicalType{Type: "long", LogicalType: "timestamp-millis"}, nil
+	}
+	return nil, fmt.Errorf("column %s has unknown transformer %s", col.OutboundName, col.Transformer)
+}
+
+// SchemaAvro returns the Avro record schema for the schema's columns.
+func SchemaAvro(schema *bpdb.AnnotatedSchema) (*AvroSchema, error) {
+	record := &AvroSchema{
+		Type:      "record",
+		Name:      safeName(schema.EventName),
+		Namespace: AvroNamespace,
+		Doc:       fmt.Sprintf("%s as of schema version %d", schema.EventName, schema.Version),
+		Fields:    make([]AvroField, 0, len(schema.Columns)),
+	}
+	for _, col := range schema.Columns {
+		t, err := AvroType(col)
+		if err != nil {
+			return nil, err
+		}
+		record.Fields = append(record.Fields, AvroField{
+			Name: safeName(col.OutboundName),
+			Type: []interface{}{"null", t},
+		})
+	}
+	return record, nil
+}
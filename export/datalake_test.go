@@ -0,0 +1,68 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func dataLakeTestSchema() *bpdb.AnnotatedSchema {
+	return &bpdb.AnnotatedSchema{
+		EventName: "minute-watched",
+		Version:   2,
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "time", Transformer: "f@timestamp@unix"},
+			{OutboundName: "user_id", Transformer: "userIDWithMapping"},
+			{OutboundName: "country", Transformer: "ipCountry"},
+			{OutboundName: "bitrate", Transformer: "float", ColumnCreationOptions: "(24)"},
+			{OutboundName: "live", Transformer: "bool"},
+		},
+	}
+}
+
+func TestSchemaAvro(t *testing.T) {
+	require := require.New(t)
+	avro, err := SchemaAvro(dataLakeTestSchema())
+	require.Nil(err)
+	b, err := json.Marshal(avro)
+	require.Nil(err)
+	require.JSONEq(`{
+		"type": "record",
+		"name": "minute_watched",
+		"namespace": "blueprint",
+		"doc": "minute-watched as of schema version 2",
+		"fields": [
+			{"name": "time", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}], "default": null},
+			{"name": "user_id", "type": ["null", "long"], "default": null},
+			{"name": "country", "type": ["null", "string"], "default": null},
+			{"name": "bitrate", "type": ["null", "float"], "default": null},
+			{"name": "live", "type": ["null", "boolean"], "default": null}
+		]
+	}`, string(b))
+
+	require.Equal("_1st_place", safeName("1st-place"))
+}
+
+func TestSchemaParquet(t *testing.T) {
+	require := require.New(t)
+	parquet, err := SchemaParquet(dataLakeTestSchema())
+	require.Nil(err)
+	require.Equal(`message minute_watched {
+  optional int64 time (TIMESTAMP_MILLIS);
+  optional int64 user_id;
+  optional binary country (UTF8);
+  optional float bitrate;
+  optional boolean live;
+}
+`, parquet)
+
+	schema := dataLakeTestSchema()
+	schema.Columns[0].Transformer = "money"
+	_, err = SchemaParquet(schema)
+	require.NotNil(err)
+	_, err = SchemaAvro(schema)
+	require.NotNil(err)
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+)
+
+// goTypes are the Go types producers use for the property each transformer reads.
+var goTypes = map[string]string{
+	"bigint":               "int64",
+	"bool":                 "bool",
+	"float":                "float64",
+	"int":                  "int32",
+	"ipAsn":                "string",
+	"ipAsnInteger":         "string",
+	"ipCity":               "string",
+	"ipCountry":            "string",
+	"ipRegion":             "string",
+	"varchar":              "string",
+	"f@timestamp@unix":     "float64",
+	"f@timestamp@unix-utc": "float64",
+	"userIDWithMapping":    "int64",
+}
+
+// goInitialisms are the words golint expects to be written in capitals.
+var goInitialisms = map[string]bool{
+	"api": true, "asn": true, "http": true, "id": true, "ip": true,
+	"json": true, "os": true, "ui": true, "url": true, "uuid": true,
+}
+
+// goIdentifier turns a name like minute-watched or user_id into an exported Go
+// identifier like MinuteWatched or UserID.
+func goIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b bytes.Buffer
+	for _, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	ident := b.String()
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// goField is a property of an event in the generated struct.
+type goField struct {
+	name      string
+	property  string
+	goType    string
+	maxLength int
+}
+
+// goFields returns the struct fields for the schema's inbound properties, in
+// the order the schema first reads them. Columns reading the same property
+// share a field, limited to the shortest varchar length among them.
+func goFields(schema *bpdb.AnnotatedSchema) ([]*goField, error) {
+	var fields []*goField
+	byProperty := make(map[string]*goField)
+	byName := make(map[string]string)
+	for _, col := range schema.Columns {
+		goType, ok := goTypes[col.Transformer]
+		if !ok {
+			return nil, fmt.Errorf("column %s has unknown transformer %s", col.OutboundName, col.Transformer)
+		}
+		field, ok := byProperty[col.InboundName]
+		if !ok {
+			field = &goField{name: goIdentifier(col.InboundName), property: col.InboundName, goType: goType}
+			if other, taken := byName[field.name]; taken {
+				return nil, fmt.Errorf("properties %s and %s of %s are both named %s in Go",
+					other, col.InboundName, schema.EventName, field.name)
+			}
+			byName[field.name] = col.InboundName
+			byProperty[col.InboundName] = field
+			fields = append(fields, field)
+		} else if field.goType != goType {
+			return nil, fmt.Errorf("property %s of %s is read as both %s and %s",
+				col.InboundName, schema.EventName, field.goType, goType)
+		}
+		if col.Transformer == "varchar" {
+			length := core.ColumnOptionsFromString(col.ColumnCreationOptions).VarcharLength()
+			if field.maxLength == 0 || length < field.maxLength {
+				field.maxLength = length
+			}
+		}
+	}
+	return fields, nil
+}
+
+type schemasByEventName []*bpdb.AnnotatedSchema
+
+func (s schemasByEventName) Len() int           { return len(s) }
+func (s schemasByEventName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s schemasByEventName) Less(i, j int) bool { return s[i].EventName < s[j].EventName }
+
+// GoStructs returns formatted Go source for package `pkg` with a struct per
+// schema. Each struct has a pointer field per inbound property with a matching
+// JSON tag, so properties the producer does not set are left out of the event
+// rather than sent as zero values, and a Validate method checking the length of
+// varchar properties. The
+// schema versions the structs were generated from are recorded in the source,
+// so producers can check whether it is stale.
+func GoStructs(pkg string, schemas []*bpdb.AnnotatedSchema) ([]byte, error) {
+	sorted := make([]*bpdb.AnnotatedSchema, len(schemas))
+	copy(sorted, schemas)
+	sort.Sort(schemasByEventName(sorted))
+
+	b := &bytes.Buffer{}
+	needsFmt := false
+	structNames := make(map[string]string, len(sorted))
+	b.WriteString("// SchemaVersions is the version of each blueprint schema this file was generated from.\n")
+	b.WriteString("var SchemaVersions = map[string]int{\n")
+	for _, schema := range sorted {
+		fmt.Fprintf(b, "%q: %d,\n", schema.EventName, schema.Version)
+	}
+	b.WriteString("}\n")
+
+	for _, schema := range sorted {
+		name := goIdentifier(schema.EventName)
+		if other, taken := structNames[name]; taken {
+			return nil, fmt.Errorf("events %s and %s are both named %s in Go", other, schema.EventName, name)
+		}
+		structNames[name] = schema.EventName
+		fields, err := goFields(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(b, "\n// %sSchemaVersion is the version of the %s schema %s was generated from.\n",
+			name, schema.EventName, name)
+		fmt.Fprintf(b, "const %sSchemaVersion = %d\n", name, schema.Version)
+		fmt.Fprintf(b, "\n// %s holds the properties of a %s event.\n", name, schema.EventName)
+		fmt.Fprintf(b, "type %s struct {\n", name)
+		for _, field := range fields {
+			fmt.Fprintf(b, "%s *%s `json:%q`\n", field.name, field.goType, field.property+",omitempty")
+		}
+		b.WriteString("}\n")
+
+		fmt.Fprintf(b, "\n// EventName returns the name of the event %s holds.\n", name)
+		fmt.Fprintf(b, "func (e *%s) EventName() string {\nreturn %q\n}\n", name, schema.EventName)
+
+		b.WriteString("\n// Validate returns an error if a property is longer than its column allows.\n")
+		fmt.Fprintf(b, "func (e *%s) Validate() error {\n", name)
+		for _, field := range fields {
+			if field.maxLength == 0 {
+				continue
+			}
+			needsFmt = true
+			fmt.Fprintf(b, "if e.%s != nil && len(*e.%s) > %d {\n", field.name, field.name, field.maxLength)
+			fmt.Fprintf(b, "return fmt.Errorf(\"%s.%s is %%d bytes, longer than the maximum of %d\", len(*e.%s))\n",
+				schema.EventName, field.property, field.maxLength, field.name)
+			b.WriteString("}\n")
+		}
+		b.WriteString("return nil\n}\n")
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by blueprint struct_generator. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	if needsFmt {
+		src.WriteString("import \"fmt\"\n\n")
+	}
+	src.Write(b.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return formatted, nil
+}
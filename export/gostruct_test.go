@@ -0,0 +1,102 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestGoIdentifier(t *testing.T) {
+	require := require.New(t)
+	require.Equal("MinuteWatched", goIdentifier("minute-watched"))
+	require.Equal("UserID", goIdentifier("user_id"))
+	require.Equal("ClientIP", goIdentifier("client_ip"))
+	require.Equal("X2fa", goIdentifier("2fa"))
+}
+
+func TestGoStructs(t *testing.T) {
+	require := require.New(t)
+	schemas := []*bpdb.AnnotatedSchema{
+		{
+			EventName: "pageview",
+			Version:   1,
+			Columns: []scoop_protocol.ColumnDefinition{
+				{InboundName: "live", OutboundName: "live", Transformer: "bool"},
+			},
+		},
+		{
+			EventName: "minute-watched",
+			Version:   7,
+			Columns: []scoop_protocol.ColumnDefinition{
+				{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix"},
+				{InboundName: "user_id", OutboundName: "user_id", Transformer: "userIDWithMapping"},
+				{InboundName: "ip", OutboundName: "city", Transformer: "ipCity"},
+				{InboundName: "ip", OutboundName: "country", Transformer: "ipCountry"},
+				{InboundName: "channel", OutboundName: "channel", Transformer: "varchar", ColumnCreationOptions: "(25)"},
+			},
+		},
+	}
+	src, err := GoStructs("events", schemas)
+	require.Nil(err)
+	require.Equal(`// Code generated by blueprint struct_generator. DO NOT EDIT.
+
+package events
+
+import "fmt"
+
+// SchemaVersions is the version of each blueprint schema this file was generated from.
+var SchemaVersions = map[string]int{
+	"minute-watched": 7,
+	"pageview":       1,
+}
+
+// MinuteWatchedSchemaVersion is the version of the minute-watched schema MinuteWatched was generated from.
+const MinuteWatchedSchemaVersion = 7
+
+// MinuteWatched holds the properties of a minute-watched event.
+type MinuteWatched struct {
+	Time    *float64 `+"`"+`json:"time,omitempty"`+"`"+`
+	UserID  *int64   `+"`"+`json:"user_id,omitempty"`+"`"+`
+	IP      *string  `+"`"+`json:"ip,omitempty"`+"`"+`
+	Channel *string  `+"`"+`json:"channel,omitempty"`+"`"+`
+}
+
+// EventName returns the name of the event MinuteWatched holds.
+func (e *MinuteWatched) EventName() string {
+	return "minute-watched"
+}
+
+// Validate returns an error if a property is longer than its column allows.
+func (e *MinuteWatched) Validate() error {
+	if e.Channel != nil && len(*e.Channel) > 25 {
+		return fmt.Errorf("minute-watched.channel is %d bytes, longer than the maximum of 25", len(*e.Channel))
+	}
+	return nil
+}
+
+// PageviewSchemaVersion is the version of the pageview schema Pageview was generated from.
+const PageviewSchemaVersion = 1
+
+// Pageview holds the properties of a pageview event.
+type Pageview struct {
+	Live *bool `+"`"+`json:"live,omitempty"`+"`"+`
+}
+
+// EventName returns the name of the event Pageview holds.
+func (e *Pageview) EventName() string {
+	return "pageview"
+}
+
+// Validate returns an error if a property is longer than its column allows.
+func (e *Pageview) Validate() error {
+	return nil
+}
+`, string(src))
+
+	schemas[0].Columns = append(schemas[0].Columns,
+		scoop_protocol.ColumnDefinition{InboundName: "live", OutboundName: "is_live", Transformer: "varchar"})
+	_, err = GoStructs("events", schemas)
+	require.NotNil(err)
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated documents.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// jsonTypes are the JSON types producers may send for each transformer.
+// ip transformers all read an address string, and timestamps are unix seconds.
+var jsonTypes = map[string][]string{
+	"bigint":               {"integer"},
+	"bool":                 {"boolean"},
+	"float":                {"number"},
+	"int":                  {"integer"},
+	"ipAsn":                {"string"},
+	"ipAsnInteger":         {"string"},
+	"ipCity":               {"string"},
+	"ipCountry":            {"string"},
+	"ipRegion":             {"string"},
+	"varchar":              {"string"},
+	"f@timestamp@unix":     {"number"},
+	"f@timestamp@unix-utc": {"number"},
+	"userIDWithMapping":    {"integer"},
+}
+
+// JSONSchemaProperty describes one inbound property of an event. Optional
+// properties also allow null.
+type JSONSchemaProperty struct {
+	Type      []string `json:"type"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+}
+
+// JSONSchema is a JSON Schema document that producers can validate an event's
+// properties against before sending it.
+type JSONSchema struct {
+	Schema      string                         `json:"$schema"`
+	Title       string                         `json:"title"`
+	Description string                         `json:"description"`
+	Type        string                         `json:"type"`
+	Properties  map[string]*JSONSchemaProperty `json:"properties"`
+	Required    []string                       `json:"required,omitempty"`
+}
+
+// mergeTypes returns the union of the JSON types, keeping their order.
+func mergeTypes(types []string, more []string) []string {
+	for _, t := range more {
+		found := false
+		for _, existing := range types {
+			if existing == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// SchemaJSONSchema returns the JSON Schema document for the inbound properties
+// of the schema. Several columns may read the same property, in which case it
+// accepts any of their types and the shortest varchar length. `required` lists
+// the properties producers must send; names the schema does not read are ignored.
+func SchemaJSONSchema(schema *bpdb.AnnotatedSchema, required []string) (*JSONSchema, error) {
+	doc := &JSONSchema{
+		Schema:      JSONSchemaDraft,
+		Title:       schema.EventName,
+		Description: fmt.Sprintf("Properties of %s as of schema version %d", schema.EventName, schema.Version),
+		Type:        "object",
+		Properties:  make(map[string]*JSONSchemaProperty, len(schema.Columns)),
+	}
+	for _, col := range schema.Columns {
+		types, ok := jsonTypes[col.Transformer]
+		if !ok {
+			return nil, fmt.Errorf("column %s has unknown transformer %s", col.OutboundName, col.Transformer)
+		}
+		prop, ok := doc.Properties[col.InboundName]
+		if !ok {
+			prop = &JSONSchemaProperty{}
+			doc.Properties[col.InboundName] = prop
+		}
+		prop.Type = mergeTypes(prop.Type, types)
+		if col.Transformer == "varchar" {
+			length := core.ColumnOptionsFromString(col.ColumnCreationOptions).VarcharLength()
+			if prop.MaxLength == nil || length < *prop.MaxLength {
+				prop.MaxLength = &length
+			}
+		}
+	}
+
+	isRequired := make(map[string]bool, len(required))
+	for _, name := range required {
+		if _, ok := doc.Properties[name]; ok && !isRequired[name] {
+			isRequired[name] = true
+			doc.Required = append(doc.Required, name)
+		}
+	}
+	sort.Strings(doc.Required)
+	for name, prop := range doc.Properties {
+		if !isRequired[name] {
+			prop.Type = append(prop.Type, "null")
+		}
+	}
+	return doc, nil
+}
+
+// RequiredProperties returns the properties listed in an event's
+// REQUIRED_PROPERTIES metadata, if it has any.
+func RequiredProperties(metadata map[string]bpdb.EventMetadataRow) []string {
+	row, ok := metadata[string(bpdb.REQUIRED_PROPERTIES)]
+	if !ok {
+		return nil
+	}
+	return bpdb.ParseRequiredProperties(row.MetadataValue)
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestSchemaJSONSchema(t *testing.T) {
+	require := require.New(t)
+	schema := &bpdb.AnnotatedSchema{
+		EventName: "minute-watched",
+		Version:   3,
+		Columns: []scoop_protocol.ColumnDefinition{
+			{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix"},
+			{InboundName: "ip", OutboundName: "city", Transformer: "ipCity"},
+			{InboundName: "ip", OutboundName: "country", Transformer: "ipCountry"},
+			{InboundName: "channel", OutboundName: "channel", Transformer: "varchar", ColumnCreationOptions: "(25)"},
+			{InboundName: "channel", OutboundName: "channel_short", Transformer: "varchar", ColumnCreationOptions: "(16)"},
+			{InboundName: "player", OutboundName: "player", Transformer: "varchar"},
+			{InboundName: "live", OutboundName: "live", Transformer: "bool"},
+		},
+	}
+	doc, err := SchemaJSONSchema(schema, []string{"time", "channel", "unknown", "time"})
+	require.Nil(err)
+	require.Equal(JSONSchemaDraft, doc.Schema)
+	require.Equal("object", doc.Type)
+	require.Equal([]string{"channel", "time"}, doc.Required)
+	require.Len(doc.Properties, 5)
+	require.Equal([]string{"number"}, doc.Properties["time"].Type)
+	require.Equal([]string{"string", "null"}, doc.Properties["ip"].Type)
+	require.Nil(doc.Properties["ip"].MaxLength)
+	require.Equal([]string{"string"}, doc.Properties["channel"].Type)
+	require.Equal(16, *doc.Properties["channel"].MaxLength)
+	require.Equal(256, *doc.Properties["player"].MaxLength)
+	require.Equal([]string{"boolean", "null"}, doc.Properties["live"].Type)
+
+	schema.Columns[0].Transformer = "money"
+	_, err = SchemaJSONSchema(schema, nil)
+	require.NotNil(err)
+}
+
+func TestRequiredProperties(t *testing.T) {
+	require := require.New(t)
+	require.Nil(RequiredProperties(nil))
+	metadata := map[string]bpdb.EventMetadataRow{
+		string(bpdb.REQUIRED_PROPERTIES): {MetadataValue: " time, channel ,,"},
+	}
+	require.Equal([]string{"time", "channel"}, RequiredProperties(metadata))
+}
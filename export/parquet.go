@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// ParquetType returns the Parquet primitive type of the column and its
+// logical type annotation, which is empty if it has none.
+func ParquetType(col scoop_protocol.ColumnDefinition) (string, string, error) {
+	switch col.Transformer {
+	case "bigint", "userIDWithMapping":
+		return "int64", "", nil
+	case "int", "ipAsnInteger":
+		return "int32", "", nil
+	case "bool":
+		return "boolean", "", nil
+	case "float":
+		if core.ColumnOptionsFromString(col.ColumnCreationOptions).FloatPrecision() <= floatMaxPrecision {
+			return "float", "", nil
+		}
+		return "double", "", nil
+	case "varchar", "ipAsn", "ipCity", "ipCountry", "ipRegion":
+		return "binary", "UTF8", nil
+	case "f@timestamp@unix", "f@timestamp@unix-utc":
+		return "int64", "TIMESTAMP_MILLIS", nil
+	}
+	return "", "", fmt.Errorf("column %s has unknown transformer %s", col.OutboundName, col.Transformer)
+}
+
+// SchemaParquet returns the Parquet message schema for the schema's columns.
+// Every column is optional, since producers may omit any property.
+func SchemaParquet(schema *bpdb.AnnotatedSchema) (string, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "message %s {\n", safeName(schema.EventName))
+	for _, col := range schema.Columns {
+		primitive, logical, err := ParquetType(col)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "  optional %s %s", primitive, safeName(col.OutboundName))
+		if logical != "" {
+			fmt.Fprintf(&b, " (%s)", logical)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
@@ -0,0 +1,221 @@
+// Package export translates blueprint schemas into the formats used by the
+// systems around it.
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/core"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+// floatMaxPrecision is the highest float precision stored as a single
+// precision float rather than a double.
+const floatMaxPrecision = 24
+
+var (
+	// fixedRedshiftTypes are the Redshift types of transformers that ignore
+	// the length in the column options.
+	fixedRedshiftTypes = map[string]string{
+		"bigint":               "bigint",
+		"bool":                 "boolean",
+		"int":                  "int",
+		"ipAsn":                "varchar(128)",
+		"ipAsnInteger":         "int",
+		"ipCity":               "varchar(64)",
+		"ipCountry":            "varchar(2)",
+		"ipRegion":             "varchar(64)",
+		"f@timestamp@unix":     "timestamp without time zone",
+		"f@timestamp@unix-utc": "timestamp without time zone",
+		"userIDWithMapping":    "bigint",
+	}
+)
+
+// RedshiftType returns the Redshift type of the column.
+func RedshiftType(col scoop_protocol.ColumnDefinition) (string, error) {
+	if t, ok := fixedRedshiftTypes[col.Transformer]; ok {
+		return t, nil
+	}
+	opts := core.ColumnOptionsFromString(col.ColumnCreationOptions)
+	switch col.Transformer {
+	case "varchar":
+		return fmt.Sprintf("varchar(%d)", opts.VarcharLength()), nil
+	case "float":
+		if opts.FloatPrecision() <= floatMaxPrecision {
+			return "real", nil
+		}
+		return "double precision", nil
+	}
+	return "", fmt.Errorf("column %s has unknown transformer %s", col.OutboundName, col.Transformer)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// columnDDL returns the definition of the column in a CREATE TABLE or ADD
+// COLUMN statement: its name, type and encoding.
+func columnDDL(col scoop_protocol.ColumnDefinition) (string, error) {
+	opts, err := core.ParseColumnOptions(col.ColumnCreationOptions)
+	if err != nil {
+		return "", fmt.Errorf("column %s has invalid options: %v", col.OutboundName, err)
+	}
+	t, err := RedshiftType(col)
+	if err != nil {
+		return "", err
+	}
+	ddl := quoteIdentifier(col.OutboundName) + " " + t
+	if opts.Encoding != "" {
+		ddl += " ENCODE " + strings.ToUpper(opts.Encoding)
+	}
+	return ddl, nil
+}
+
+// CreateTableDDL returns the statement creating the Redshift table for the
+// schema. Columns marked distkey or sortkey in their creation options become
+// the table's distribution and sort keys, and their encodings become the
+// columns' compression encodings.
+func CreateTableDDL(schema *bpdb.AnnotatedSchema) (string, error) {
+	var b bytes.Buffer
+	var distKeys, sortKeys []string
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n", quoteIdentifier(schema.EventName))
+	for i, col := range schema.Columns {
+		def, err := columnDDL(col)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "    %s", def)
+		if i < len(schema.Columns)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+		opts := core.ColumnOptionsFromString(col.ColumnCreationOptions)
+		if opts.DistKey {
+			distKeys = append(distKeys, quoteIdentifier(col.OutboundName))
+		}
+		if opts.SortKey {
+			sortKeys = append(sortKeys, quoteIdentifier(col.OutboundName))
+		}
+	}
+	b.WriteString(")")
+	if len(distKeys) > 1 {
+		return "", fmt.Errorf("schema %s has more than one distkey: %s", schema.EventName, strings.Join(distKeys, ", "))
+	}
+	if len(distKeys) == 1 {
+		fmt.Fprintf(&b, "\nDISTKEY(%s)", distKeys[0])
+	}
+	if len(sortKeys) > 0 {
+		fmt.Fprintf(&b, "\nSORTKEY(%s)", strings.Join(sortKeys, ", "))
+	}
+	b.WriteString(";\n")
+	return b.String(), nil
+}
+
+// retypeDDL returns the statements changing the type of a column of the
+// Redshift table `name` to that of `col`. Redshift can only alter the type of
+// a varchar column in place, to make it longer, so other columns are rebuilt:
+// the new column is added, filled from the old one, and renamed over it once
+// it is dropped. A float that stays single precision needs no change; one
+// that becomes double precision is rebuilt even if it already was, since the
+// operation does not record the old precision.
+func retypeDDL(name string, col scoop_protocol.ColumnDefinition) (string, error) {
+	t, err := RedshiftType(col)
+	if err != nil {
+		return "", err
+	}
+	column := quoteIdentifier(col.OutboundName)
+	switch {
+	case col.Transformer == "varchar":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;\n", name, column, t), nil
+	case col.Transformer == "float" && t == "real":
+		return fmt.Sprintf("-- %s stays real, no change needed\n", column), nil
+	}
+	def, err := columnDDL(scoop_protocol.ColumnDefinition{
+		OutboundName:          col.OutboundName + "_retyped",
+		Transformer:           col.Transformer,
+		ColumnCreationOptions: col.ColumnCreationOptions,
+	})
+	if err != nil {
+		return "", err
+	}
+	retyped := quoteIdentifier(col.OutboundName + "_retyped")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "-- Redshift cannot change %s to %s in place, so it is rebuilt as the last column of the table\n", column, t)
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN %s;\n", name, def)
+	fmt.Fprintf(&b, "UPDATE %s SET %s = %s;\n", name, retyped, column)
+	fmt.Fprintf(&b, "ALTER TABLE %s DROP COLUMN %s;\n", name, column)
+	fmt.Fprintf(&b, "ALTER TABLE %s RENAME COLUMN %s TO %s;\n", name, retyped, column)
+	return b.String(), nil
+}
+
+// MigrationDDL returns the statements migrating the Redshift table for
+// `table` through the given operations, which start after version `from`. A
+// `from` of -1 means the table does not exist yet. Operations applied while
+// the table does not exist are collected into a CREATE TABLE statement.
+func MigrationDDL(table string, from int, operations []*scoop_protocol.Operation) (string, error) {
+	var b bytes.Buffer
+	name := quoteIdentifier(table)
+	var pending *bpdb.AnnotatedSchema
+	if from < 0 {
+		pending = &bpdb.AnnotatedSchema{EventName: table}
+	}
+	for _, op := range operations {
+		if pending != nil {
+			if err := bpdb.ApplyOperation(pending, *op); err != nil {
+				return "", fmt.Errorf("applying operation of version %d: %v", op.Version, err)
+			}
+			continue
+		}
+		switch op.Action {
+		case scoop_protocol.ADD:
+			col := scoop_protocol.ColumnDefinition{
+				OutboundName:          op.Name,
+				Transformer:           op.ActionMetadata["column_type"],
+				ColumnCreationOptions: op.ActionMetadata["column_options"],
+			}
+			def, err := columnDDL(col)
+			if err != nil {
+				return "", err
+			}
+			if opts := core.ColumnOptionsFromString(col.ColumnCreationOptions); opts.DistKey || opts.SortKey {
+				fmt.Fprintf(&b, "-- %s is a key, which cannot be added to an existing table\n", quoteIdentifier(op.Name))
+			}
+			fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN %s;\n", name, def)
+		case scoop_protocol.DELETE:
+			fmt.Fprintf(&b, "ALTER TABLE %s DROP COLUMN %s;\n", name, quoteIdentifier(op.Name))
+		case scoop_protocol.RENAME:
+			fmt.Fprintf(&b, "ALTER TABLE %s RENAME COLUMN %s TO %s;\n",
+				name, quoteIdentifier(op.Name), quoteIdentifier(op.ActionMetadata["new_outbound"]))
+		case bpdb.RETYPE:
+			ddl, err := retypeDDL(name, scoop_protocol.ColumnDefinition{
+				OutboundName:          op.Name,
+				Transformer:           op.ActionMetadata["column_type"],
+				ColumnCreationOptions: op.ActionMetadata["column_options"],
+			})
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(ddl)
+		case scoop_protocol.REQUEST_DROP_EVENT:
+			fmt.Fprintf(&b, "-- drop of %s requested: %s\n", name, op.ActionMetadata["reason"])
+		case scoop_protocol.CANCEL_DROP_EVENT:
+			fmt.Fprintf(&b, "-- drop request of %s cancelled\n", name)
+		case scoop_protocol.DROP_EVENT:
+			fmt.Fprintf(&b, "DROP TABLE IF EXISTS %s;\n", name)
+			pending = &bpdb.AnnotatedSchema{EventName: table}
+		default:
+			return "", fmt.Errorf("unsupported operation action %s", op.Action)
+		}
+	}
+	if pending != nil && len(pending.Columns) > 0 {
+		ddl, err := CreateTableDDL(pending)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(ddl)
+	}
+	return b.String(), nil
+}
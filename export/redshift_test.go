@@ -0,0 +1,89 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/scoop_protocol/scoop_protocol"
+)
+
+func TestCreateTableDDL(t *testing.T) {
+	require := require.New(t)
+	schema := &bpdb.AnnotatedSchema{
+		EventName: "minute-watched",
+		Columns: []scoop_protocol.ColumnDefinition{
+			{OutboundName: "time", Transformer: "f@timestamp@unix", ColumnCreationOptions: " sortkey"},
+			{OutboundName: "user_id", Transformer: "userIDWithMapping", ColumnCreationOptions: " distkey"},
+			{OutboundName: "channel", Transformer: "varchar", ColumnCreationOptions: "(25)"},
+			{OutboundName: "country", Transformer: "ipCountry"},
+			{OutboundName: "player", Transformer: "varchar", ColumnCreationOptions: " encode zstd"},
+			{OutboundName: "bitrate", Transformer: "float", ColumnCreationOptions: "(24) encode raw"},
+		},
+	}
+	ddl, err := CreateTableDDL(schema)
+	require.Nil(err)
+	require.Equal(`CREATE TABLE "minute-watched" (
+    "time" timestamp without time zone,
+    "user_id" bigint,
+    "channel" varchar(25),
+    "country" varchar(2),
+    "player" varchar(256) ENCODE ZSTD,
+    "bitrate" real ENCODE RAW
+)
+DISTKEY("user_id")
+SORTKEY("time");
+`, ddl)
+
+	schema.Columns[2].ColumnCreationOptions = "(25) distkey"
+	_, err = CreateTableDDL(schema)
+	require.NotNil(err)
+}
+
+func TestMigrationDDL(t *testing.T) {
+	require := require.New(t)
+	ops := []*scoop_protocol.Operation{}
+	for _, op := range []scoop_protocol.Operation{
+		scoop_protocol.NewAddOperation("os", "os", "varchar", "(16) encode lzo", ""),
+		scoop_protocol.NewDeleteOperation("backend"),
+		scoop_protocol.NewRenameOperation("quality", "video_quality"),
+		bpdb.NewRetypeOperation("channel", "varchar", "(64)"),
+		bpdb.NewRetypeOperation("minutes_logged", "bigint", ""),
+		bpdb.NewRetypeOperation("bitrate", "float", "(53) encode zstd"),
+		bpdb.NewRetypeOperation("volume", "float", "(20)"),
+		scoop_protocol.NewDropEventOperation("unused"),
+		scoop_protocol.NewAddOperation("time", "time", "f@timestamp@unix", "", ""),
+	} {
+		op := op
+		ops = append(ops, &op)
+	}
+	ddl, err := MigrationDDL("test", 3, ops)
+	require.Nil(err)
+	require.Equal(`ALTER TABLE "test" ADD COLUMN "os" varchar(16) ENCODE LZO;
+ALTER TABLE "test" DROP COLUMN "backend";
+ALTER TABLE "test" RENAME COLUMN "quality" TO "video_quality";
+ALTER TABLE "test" ALTER COLUMN "channel" TYPE varchar(64);
+-- Redshift cannot change "minutes_logged" to bigint in place, so it is rebuilt as the last column of the table
+ALTER TABLE "test" ADD COLUMN "minutes_logged_retyped" bigint;
+UPDATE "test" SET "minutes_logged_retyped" = "minutes_logged";
+ALTER TABLE "test" DROP COLUMN "minutes_logged";
+ALTER TABLE "test" RENAME COLUMN "minutes_logged_retyped" TO "minutes_logged";
+-- Redshift cannot change "bitrate" to double precision in place, so it is rebuilt as the last column of the table
+ALTER TABLE "test" ADD COLUMN "bitrate_retyped" double precision ENCODE ZSTD;
+UPDATE "test" SET "bitrate_retyped" = "bitrate";
+ALTER TABLE "test" DROP COLUMN "bitrate";
+ALTER TABLE "test" RENAME COLUMN "bitrate_retyped" TO "bitrate";
+-- "volume" stays real, no change needed
+DROP TABLE IF EXISTS "test";
+CREATE TABLE "test" (
+    "time" timestamp without time zone
+);
+`, ddl)
+
+	ddl, err = MigrationDDL("test", -1, ops[:1])
+	require.Nil(err)
+	require.Equal(`CREATE TABLE "test" (
+    "os" varchar(16) ENCODE LZO
+);
+`, ddl)
+}
@@ -1,10 +1,14 @@
 DO $$
 BEGIN
   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'action') THEN
-    CREATE TYPE action AS ENUM ('add', 'delete', 'rename', 'request_drop_event', 'drop_event', 'cancel_drop_event');
+    CREATE TYPE action AS ENUM ('add', 'delete', 'rename', 'request_drop_event', 'drop_event', 'cancel_drop_event', 'retype', 'deprecate', 'classify');
   END IF;
 END $$;
 
+ALTER TYPE action ADD VALUE IF NOT EXISTS 'retype';
+ALTER TYPE action ADD VALUE IF NOT EXISTS 'deprecate';
+ALTER TYPE action ADD VALUE IF NOT EXISTS 'classify';
+
 CREATE TABLE IF NOT EXISTS operation
 (
   event varchar,
@@ -18,6 +22,19 @@ CREATE TABLE IF NOT EXISTS operation
   PRIMARY KEY (event, version, ordering)
 );
 
+-- The materialized schema of each event at each version, written in the same
+-- transaction as its operations so the current schemas can be read without
+-- replaying the whole operation table.
+CREATE TABLE IF NOT EXISTS schema_snapshot
+(
+  event varchar,
+  version int,
+  format int,
+  schema jsonb,
+  ts timestamp without time zone default NOW(),
+  PRIMARY KEY (event, version)
+);
+
 DO $$
 BEGIN
   IF NOT EXISTS (SELECT 1 FROM pg_tables WHERE tablename = 'global_maintenance') THEN
@@ -49,6 +66,34 @@ BEGIN
   END IF;
 END $$;
 
+-- Every version of the doc of each column. Docs are keyed by outbound column
+-- name and copied to the new name when a column is renamed.
+CREATE TABLE IF NOT EXISTS column_doc
+(
+  event varchar,
+  column_name varchar,
+  description varchar,
+  examples jsonb,
+  owner varchar,
+  units varchar,
+  ts timestamp without time zone default NOW(),
+  user_name varchar,
+  version int,
+  PRIMARY KEY (event, column_name, version)
+);
+
+-- Every change to the event name blacklist. A pattern is blacklisted if its
+-- latest row is.
+CREATE TABLE IF NOT EXISTS event_blacklist
+(
+  version serial PRIMARY KEY,
+  pattern text,
+  blacklisted boolean,
+  "user" text,
+  reason varchar,
+  ts timestamp without time zone default NOW()
+);
+
 DO $$
 BEGIN
   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'stream_type') THEN
@@ -79,10 +124,17 @@ CREATE TABLE IF NOT EXISTS kinesis_config
 DO $$
   BEGIN
   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'event_metadata_type') THEN
-    CREATE TYPE event_metadata_type AS ENUM ('comment', 'edge_type', 'datastores', 'birth');
+    CREATE TYPE event_metadata_type AS ENUM ('comment', 'edge_type', 'datastores', 'birth', 'required_properties',
+      'owner_team', 'oncall_contact', 'retention_days', 'sla_tier');
   END IF;
 END $$;
 
+ALTER TYPE event_metadata_type ADD VALUE IF NOT EXISTS 'required_properties';
+ALTER TYPE event_metadata_type ADD VALUE IF NOT EXISTS 'owner_team';
+ALTER TYPE event_metadata_type ADD VALUE IF NOT EXISTS 'oncall_contact';
+ALTER TYPE event_metadata_type ADD VALUE IF NOT EXISTS 'retention_days';
+ALTER TYPE event_metadata_type ADD VALUE IF NOT EXISTS 'sla_tier';
+
 -- This tables keeps track of the only the current event metadata
 CREATE TABLE IF NOT EXISTS event_metadata
 (
@@ -94,6 +94,16 @@ func main() {
 	if err != nil {
 		logger.WithError(err).Fatal("Error setting up blueprint schema backend")
 	}
+	if !*readonly {
+		// Schemas are read from their snapshots, so backfill any that are
+		// missing or out of date before serving.
+		drift, err := bpSchemaBackend.RebuildSnapshots()
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to rebuild schema snapshots")
+		} else if len(drift) > 0 {
+			logger.WithField("events", len(drift)).Info("Rebuilt schema snapshots")
+		}
+	}
 	bpKinesisConfigBackend := bpdb.NewKinesisConfigBackend(db, conf.KinesisFilters)
 
 	ingCont := ingester.NewController(*ingesterURL)
@@ -78,6 +78,34 @@ angular.module('blueprint.schema.show', [
           return datastores.join(", ");
     }
 
+    // sunsetPassed returns whether a column with the given deprecation may be
+    // dropped on `today`, a date in the YYYY-MM-DD format of sunsets.
+    $scope.sunsetPassed = function(deprecation, today) {
+      return !!deprecation && deprecation.Sunset <= today;
+    }
+
+    // deprecationError returns why the staged deprecation of column `name` is
+    // invalid on `today`, or '' if it is valid.
+    $scope.deprecationError = function(name, deprecation, today) {
+      if (name == "time") {
+        return "Cannot deprecate the time column.";
+      }
+      if (!deprecation.Reason || !deprecation.Reason.trim()) {
+        return "Deprecating " + name + " needs a reason.";
+      }
+      if (!/^\d{4}-\d{2}-\d{2}$/.test(deprecation.Sunset)) {
+        return "Sunset of " + name + " must be a date like 2017-12-31.";
+      }
+      if (deprecation.Sunset < today) {
+        return "Sunset of " + name + " cannot be in the past.";
+      }
+      return '';
+    }
+
+    $scope.today = function() {
+      return new Date().toISOString().slice(0, 10);
+    }
+
     $scope.setEventMetadata = function(data) {
       Object.keys(data.Metadata).forEach(function(metadataType) {
           if (metadataType == "birth") {
@@ -199,6 +227,7 @@ angular.module('blueprint.schema.show', [
       $scope.schema = schema;
       $scope.additions = {Columns: []}; // Used to hold new columns
       $scope.deletes = {ColInds: []}; // Used to hold dropped columns
+      $scope.deprecations = {}; // Used to hold deprecated columns {outboundName: {Reason: ..., Sunset: ...}, ...}
       $scope.nameMap = {}; // Used to hold renamed outbound names {originalName: newName, ...}
       angular.forEach($scope.schema.Columns, function(col, i){
         $scope.nameMap[col.OutboundName] = col.OutboundName;
@@ -259,6 +288,26 @@ angular.module('blueprint.schema.show', [
         }
         return true;
       };
+      $scope.columnDeprecation = function(colInd) {
+        var deprecations = $scope.schema.Deprecations || {};
+        return deprecations[$scope.schema.Columns[colInd].OutboundName];
+      };
+      // Columns can only be dropped once they are deprecated and their sunset has passed
+      $scope.columnIsDroppable = function(colInd) {
+        return $scope.columnIsDeletable(colInd) && $scope.sunsetPassed($scope.columnDeprecation(colInd), $scope.today());
+      };
+      $scope.columnAlreadyStagedForDeprecation = function(colInd) {
+        return $scope.schema.Columns[colInd].OutboundName in $scope.deprecations;
+      };
+      $scope.deprecateColumn = function(colInd) {
+        $scope.deprecations[$scope.schema.Columns[colInd].OutboundName] = {Reason: '', Sunset: ''};
+      };
+      $scope.undoDeprecateColumn = function(colInd) {
+        delete $scope.deprecations[$scope.schema.Columns[colInd].OutboundName];
+      };
+      $scope.numDeprecations = function() {
+        return Object.keys($scope.deprecations).length;
+      };
       $scope.deleteColumnFromSchema = function(colInd) {
         $scope.deletes.ColInds.push(colInd);
       };
@@ -373,6 +422,22 @@ angular.module('blueprint.schema.show', [
           return false;
         }
 
+        // Check that every deprecation has a reason and a sunset that has not passed
+        var deprecations = [];
+        var today = $scope.today();
+        if (!Object.keys($scope.deprecations).every(function(name) {
+          var deprecation = $scope.deprecations[name];
+          var error = $scope.deprecationError(name, deprecation, today);
+          if (error) {
+            Store.setError(error);
+            return false;
+          }
+          deprecations.push({OutboundName: name, Reason: deprecation.Reason.trim(), Sunset: deprecation.Sunset});
+          return true;
+        })) {
+          return false;
+        }
+
         // Check that none of the added columns or the renames are blacklisted
         // outbound names
         if (!$scope.additions.Columns.every(function (col) {
@@ -482,7 +547,7 @@ angular.module('blueprint.schema.show', [
         }
 
         // Nothing was modified, so stop here
-        if (additions.Columns.length + deletes.length + Object.keys(renames).length < 1) {
+        if (additions.Columns.length + deletes.length + Object.keys(renames).length + deprecations.length < 1) {
           Store.setError("No change to columns, so no action taken.", undefined);
           return false;
         }
@@ -490,7 +555,7 @@ angular.module('blueprint.schema.show', [
         // We verified that we have valid things to do, so proceed with update!
         Schema.update(
           {event: schema.EventName},
-          {additions: additions.Columns, deletes: deletes, renames: renames},
+          {additions: additions.Columns, deletes: deletes, renames: renames, deprecations: deprecations},
           function() {
             Store.setMessage("Succesfully updated schema: " +  schema.EventName);
             // update front-end schema
@@ -502,6 +567,11 @@ angular.module('blueprint.schema.show', [
               }
             }
             $scope.deletes = {ColInds: []};
+            $scope.schema.Deprecations = $scope.schema.Deprecations || {};
+            angular.forEach(deprecations, function(d) {
+              $scope.schema.Deprecations[d.OutboundName] = {Reason: d.Reason, Sunset: d.Sunset};
+            });
+            $scope.deprecations = {};
             angular.forEach($scope.additions.Columns, function(c) {
               $scope.schema.Columns.push(c);
               $scope.nameMap[c.OutboundName] = c.OutboundName
@@ -511,6 +581,10 @@ angular.module('blueprint.schema.show', [
                 var newName = renames[c.OutboundName];
                 delete $scope.nameMap[c.OutboundName];
                 $scope.nameMap[newName] = newName;
+                if (c.OutboundName in $scope.schema.Deprecations) {
+                  $scope.schema.Deprecations[newName] = $scope.schema.Deprecations[c.OutboundName];
+                  delete $scope.schema.Deprecations[c.OutboundName];
+                }
                 c.OutboundName = newName;
               }
             });
@@ -32,6 +32,22 @@ describe('blueprint.schema.show module', function() {
       $scope.setEventMetadata(metadataBody);
       expect($scope.eventMetadata).toBeDefined();
     }));
+    it('Only lets deprecated columns be dropped once their sunset has passed', inject(function() {
+      var $scope = {};
+      controller = $controller('ShowSchema', { $scope: $scope });
+      expect($scope.sunsetPassed(undefined, '2017-10-01')).toBe(false);
+      expect($scope.sunsetPassed({Reason: 'unused', Sunset: '2017-10-02'}, '2017-10-01')).toBe(false);
+      expect($scope.sunsetPassed({Reason: 'unused', Sunset: '2017-10-01'}, '2017-10-01')).toBe(true);
+    }));
+    it('Requires a reason and a future sunset to deprecate a column', inject(function() {
+      var $scope = {};
+      controller = $controller('ShowSchema', { $scope: $scope });
+      expect($scope.deprecationError('backend', {Reason: 'use backend_id', Sunset: '2017-11-01'}, '2017-10-01')).toBe('');
+      expect($scope.deprecationError('backend', {Reason: ' ', Sunset: '2017-11-01'}, '2017-10-01')).toContain('reason');
+      expect($scope.deprecationError('backend', {Reason: 'unused', Sunset: 'next week'}, '2017-10-01')).toContain('must be a date');
+      expect($scope.deprecationError('backend', {Reason: 'unused', Sunset: '2017-09-01'}, '2017-10-01')).toContain('in the past');
+      expect($scope.deprecationError('time', {Reason: 'unused', Sunset: '2017-11-01'}, '2017-10-01')).toContain('time column');
+    }));
     it('Correctly sets an error if no target datastore is in event metadata', inject(function() {
       var $scope = {};
       var metadataBody = {"EventName": "whatever",
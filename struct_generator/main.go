@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/twitchscience/aws_utils/logger"
+	"github.com/twitchscience/blueprint/bpdb"
+	"github.com/twitchscience/blueprint/export"
+)
+
+var (
+	blueprintURL = flag.String("blueprint", "http://localhost:8080", "The base URL of the blueprint API")
+	eventName    = flag.String("event", "", "The event to generate a struct for; all events if empty")
+	packageName  = flag.String("package", "events", "The package of the generated code")
+	outFile      = flag.String("out", "", "The file to write the generated code to; stdout if empty")
+)
+
+// fetchSchemas returns the current schema of `event`, or of every event if it is empty.
+func fetchSchemas(baseURL string, event string) ([]*bpdb.AnnotatedSchema, error) {
+	path := "/schemas"
+	if event != "" {
+		path = "/schema/" + url.QueryEscape(event)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(strings.TrimRight(baseURL, "/") + path)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %v", path, err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			logger.WithError(err).Error("Failed to close response body")
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("requesting %s: status %d: %s", path, resp.StatusCode, body)
+	}
+	var schemas []*bpdb.AnnotatedSchema
+	err = json.NewDecoder(resp.Body).Decode(&schemas)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", path, err)
+	}
+	return schemas, nil
+}
+
+func main() {
+	flag.Parse()
+	logger.Init("info")
+
+	schemas, err := fetchSchemas(*blueprintURL, *eventName)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to fetch schemas")
+	}
+	src, err := export.GoStructs(*packageName, schemas)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to generate Go structs")
+	}
+	if *outFile == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*outFile, src, 0644)
+	}
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to write generated code")
+	}
+}
@@ -16,6 +16,8 @@ type MockBpdb struct {
 	maintenanceModes map[string]bpdb.MaintenanceMode
 	mockActiveUsers  []*bpdb.ActiveUser
 	mockDailyChanges []*bpdb.DailyChange
+	blacklistMutex   *sync.RWMutex
+	blacklist        []bpdb.BlacklistChange
 }
 
 // MockBpSchemaBackend is a mock for the bpdb/BpSchemaBackend interface which tracks how many times AllSchemas has been called
@@ -25,6 +27,8 @@ type MockBpSchemaBackend struct {
 	allSchemasCalls       int32
 	allEventMetadataCalls int32
 	metadataState         map[string](map[string]bpdb.EventMetadataRow)
+	columnDocs            map[string]map[string]bpdb.ColumnDoc
+	metadataHistory       map[string][]bpdb.EventMetadataChange
 }
 
 // MockBpKinesisConfigBackend is a mock for the bpdb/BpKinesisConfigBackend interface
@@ -33,12 +37,14 @@ type MockBpKinesisConfigBackend struct {
 
 // NewMockBpdb creates a new mock backend.
 func NewMockBpdb(mm map[string]bpdb.MaintenanceMode, activeUsers []*bpdb.ActiveUser, dailyChanges []*bpdb.DailyChange) *MockBpdb {
-	return &MockBpdb{&sync.RWMutex{}, bpdb.MaintenanceMode{IsInMaintenanceMode: false, User: ""}, mm, activeUsers, dailyChanges}
+	return &MockBpdb{&sync.RWMutex{}, bpdb.MaintenanceMode{IsInMaintenanceMode: false, User: ""}, mm, activeUsers, dailyChanges,
+		&sync.RWMutex{}, []bpdb.BlacklistChange{}}
 }
 
 // NewMockBpSchemaBackend creates a new mock schema backend.
 func NewMockBpSchemaBackend(initMetadata map[string]map[string]bpdb.EventMetadataRow) *MockBpSchemaBackend {
-	return &MockBpSchemaBackend{&sync.RWMutex{}, &sync.RWMutex{}, 0, 0, initMetadata}
+	return &MockBpSchemaBackend{&sync.RWMutex{}, &sync.RWMutex{}, 0, 0, initMetadata,
+		make(map[string]map[string]bpdb.ColumnDoc), make(map[string][]bpdb.EventMetadataChange)}
 }
 
 // NewMockBpKinesisConfigBackend creates a new mock kinesis config backend.
@@ -61,11 +67,22 @@ func (m *MockBpSchemaBackend) AllSchemas() ([]bpdb.AnnotatedSchema, error) {
 	return make([]bpdb.AnnotatedSchema, 0), nil
 }
 
-// Schema returns nils except when the event name is "this-table-exists" or "this-event-exists"
+// Schema returns nils except when the event name is "this-table-exists" or "this-event-exists",
+// or "this-legacy-table-exists", whose columns predate the lint rules.
 func (m *MockBpSchemaBackend) Schema(name string, version *int) (*bpdb.AnnotatedSchema, error) {
 	if name == "this-table-exists" || name == "this-event-exists" {
 		return &bpdb.AnnotatedSchema{}, nil
 	}
+	if name == "this-legacy-table-exists" {
+		return &bpdb.AnnotatedSchema{
+			EventName: name,
+			Version:   1,
+			Columns: []scoop_protocol.ColumnDefinition{
+				{InboundName: "time", OutboundName: "time", Transformer: "f@timestamp@unix"},
+				{InboundName: "user", OutboundName: "user", Transformer: "bigint"},
+			},
+		}, nil
+	}
 	return nil, nil
 }
 
@@ -74,21 +91,80 @@ func (m *MockBpSchemaBackend) UpdateSchema(update *core.ClientUpdateSchemaReques
 	return nil
 }
 
+// RevertSchema returns nil.
+func (m *MockBpSchemaBackend) RevertSchema(eventName string, toVersion int, user string, force bool) *core.WebError {
+	return nil
+}
+
 // CreateSchema returns nil.
-func (m *MockBpSchemaBackend) CreateSchema(schema *scoop_protocol.Config, user string) *core.WebError {
+func (m *MockBpSchemaBackend) CreateSchema(schema *core.ClientCreateSchemaRequest, user string) *core.WebError {
 	return nil
 }
 
+// PreviewCreateSchema returns an empty preview.
+func (m *MockBpSchemaBackend) PreviewCreateSchema(schema *core.ClientCreateSchemaRequest) (*bpdb.SchemaPreview, *core.WebError) {
+	return &bpdb.SchemaPreview{EventName: schema.EventName}, nil
+}
+
+// PreviewUpdateSchema returns an empty preview.
+func (m *MockBpSchemaBackend) PreviewUpdateSchema(update *core.ClientUpdateSchemaRequest) (*bpdb.SchemaPreview, *core.WebError) {
+	return &bpdb.SchemaPreview{EventName: update.EventName}, nil
+}
+
 // Migration returns nils.
 func (m *MockBpSchemaBackend) Migration(table string, from int, to int) ([]*scoop_protocol.Operation, error) {
 	return nil, nil
 }
 
+// SchemaDiff returns nils.
+func (m *MockBpSchemaBackend) SchemaDiff(name string, from int, to int) (*bpdb.SchemaDiff, error) {
+	return nil, nil
+}
+
+// SchemaHistory returns nils.
+func (m *MockBpSchemaBackend) SchemaHistory(name string, offset int, limit int) (*bpdb.SchemaHistory, error) {
+	return nil, nil
+}
+
 // DropSchema return nil.
 func (m *MockBpSchemaBackend) DropSchema(schema *bpdb.AnnotatedSchema, reason string, exists bool, user string) error {
 	return nil
 }
 
+// PreviewRestoreSchema returns an empty preview.
+func (m *MockBpSchemaBackend) PreviewRestoreSchema(eventName string, reason string) (*bpdb.SchemaPreview, *core.WebError) {
+	return &bpdb.SchemaPreview{EventName: eventName}, nil
+}
+
+// UpdateSchemas returns a result for each update.
+func (m *MockBpSchemaBackend) UpdateSchemas(updates []*core.ClientUpdateSchemaRequest, user string) ([]bpdb.BulkUpdateResult, *core.WebError) {
+	results := make([]bpdb.BulkUpdateResult, 0, len(updates))
+	for _, update := range updates {
+		results = append(results, bpdb.BulkUpdateResult{EventName: update.EventName})
+	}
+	return results, nil
+}
+
+// CloneSchema returns nil.
+func (m *MockBpSchemaBackend) CloneSchema(req *core.ClientCloneSchemaRequest, user string) *core.WebError {
+	return nil
+}
+
+// RestoreSchema returns nil.
+func (m *MockBpSchemaBackend) RestoreSchema(eventName string, reason string, user string) *core.WebError {
+	return nil
+}
+
+// SnapshotDrift returns no drift.
+func (m *MockBpSchemaBackend) SnapshotDrift() ([]bpdb.SnapshotDrift, error) {
+	return []bpdb.SnapshotDrift{}, nil
+}
+
+// RebuildSnapshots returns no drift.
+func (m *MockBpSchemaBackend) RebuildSnapshots() ([]bpdb.SnapshotDrift, error) {
+	return []bpdb.SnapshotDrift{}, nil
+}
+
 // AllEventMetadata increments the number of AllEventMetadata calls
 func (m *MockBpSchemaBackend) AllEventMetadata() (*bpdb.AllEventMetadata, error) {
 	m.allEventMetadataMutex.Lock()
@@ -104,17 +180,64 @@ func (m *MockBpSchemaBackend) GetAllEventMetadataCalls() int32 {
 	return m.allEventMetadataCalls
 }
 
-// UpdateEventMetadata returns nil if update.EventName is in the returnMap
+// UpdateEventMetadata returns nil if update.EventName is in the returnMap,
+// recording the update as a new version.
 func (m *MockBpSchemaBackend) UpdateEventMetadata(update *core.ClientUpdateEventMetadataRequest, user string) *core.WebError {
 	if _, exists := m.metadataState[update.EventName]; exists {
-		m.metadataState[update.EventName][string(update.MetadataType)] = bpdb.EventMetadataRow{
+		row := bpdb.EventMetadataRow{
 			MetadataValue: update.MetadataValue,
+			UserName:      user,
+			Version:       m.metadataState[update.EventName][string(update.MetadataType)].Version + 1,
 		}
+		m.metadataState[update.EventName][string(update.MetadataType)] = row
+		m.metadataHistory[update.EventName] = append([]bpdb.EventMetadataChange{{
+			MetadataType:  string(update.MetadataType),
+			MetadataValue: row.MetadataValue,
+			UserName:      row.UserName,
+			Version:       row.Version,
+		}}, m.metadataHistory[update.EventName]...)
 		return nil
 	}
 	return core.NewUserWebError(errors.New("schema does not exist"))
 }
 
+// EventMetadataHistory returns the updates made through UpdateEventMetadata,
+// newest first.
+func (m *MockBpSchemaBackend) EventMetadataHistory(eventName string) ([]bpdb.EventMetadataChange, error) {
+	return append([]bpdb.EventMetadataChange{}, m.metadataHistory[eventName]...), nil
+}
+
+// ColumnDocs returns the docs stored by UpdateColumnDoc for the event.
+func (m *MockBpSchemaBackend) ColumnDocs(eventName string) (map[string]bpdb.ColumnDoc, error) {
+	return m.columnDocs[eventName], nil
+}
+
+// AllColumnDocs returns the docs stored by UpdateColumnDoc.
+func (m *MockBpSchemaBackend) AllColumnDocs() (map[string]map[string]bpdb.ColumnDoc, error) {
+	return m.columnDocs, nil
+}
+
+// UpdateColumnDoc stores the doc if the event exists.
+func (m *MockBpSchemaBackend) UpdateColumnDoc(req *core.ClientUpdateColumnDocRequest, user string) *core.WebError {
+	schema, _ := m.Schema(req.EventName, nil)
+	if schema == nil {
+		return core.NewUserWebError(errors.New("schema does not exist"))
+	}
+	if _, exists := m.columnDocs[req.EventName]; !exists {
+		m.columnDocs[req.EventName] = make(map[string]bpdb.ColumnDoc)
+	}
+	doc := m.columnDocs[req.EventName][req.Column]
+	m.columnDocs[req.EventName][req.Column] = bpdb.ColumnDoc{
+		Description: req.Description,
+		Examples:    req.Examples,
+		Owner:       req.Owner,
+		Units:       req.Units,
+		UserName:    user,
+		Version:     doc.Version + 1,
+	}
+	return nil
+}
+
 // AllKinesisConfigs returns nil
 func (m *MockBpKinesisConfigBackend) AllKinesisConfigs() ([]scoop_protocol.AnnotatedKinesisConfig, error) {
 	return make([]scoop_protocol.AnnotatedKinesisConfig, 0), nil
@@ -178,3 +301,69 @@ func (m *MockBpdb) GetSchemaMaintenanceMode(schema string) (bpdb.MaintenanceMode
 func (m *MockBpdb) SetSchemaMaintenanceMode(schema string, switchingOn bool, user, reason string) error {
 	return nil
 }
+
+// BlacklistPatterns returns the patterns whose latest change blacklisted them.
+func (m *MockBpdb) BlacklistPatterns() ([]bpdb.BlacklistChange, error) {
+	m.blacklistMutex.RLock()
+	defer m.blacklistMutex.RUnlock()
+	latest := make(map[string]bpdb.BlacklistChange)
+	var order []string
+	for _, change := range m.blacklist {
+		if _, ok := latest[change.Pattern]; !ok {
+			order = append(order, change.Pattern)
+		}
+		latest[change.Pattern] = change
+	}
+	patterns := []bpdb.BlacklistChange{}
+	for _, pattern := range order {
+		if latest[pattern].Blacklisted {
+			patterns = append(patterns, latest[pattern])
+		}
+	}
+	return patterns, nil
+}
+
+// BlacklistHistory returns the blacklist changes, newest first.
+func (m *MockBpdb) BlacklistHistory() ([]bpdb.BlacklistChange, error) {
+	m.blacklistMutex.RLock()
+	defer m.blacklistMutex.RUnlock()
+	history := make([]bpdb.BlacklistChange, 0, len(m.blacklist))
+	for i := len(m.blacklist) - 1; i >= 0; i-- {
+		history = append(history, m.blacklist[i])
+	}
+	return history, nil
+}
+
+// SetBlacklistPattern records the change in memory and returns nil.
+func (m *MockBpdb) SetBlacklistPattern(pattern string, blacklisted bool, user, reason string) error {
+	m.blacklistMutex.Lock()
+	defer m.blacklistMutex.Unlock()
+	m.blacklist = append(m.blacklist, bpdb.BlacklistChange{
+		Version:     len(m.blacklist) + 1,
+		Pattern:     pattern,
+		Blacklisted: blacklisted,
+		User:        user,
+		Reason:      reason,
+	})
+	return nil
+}
+
+// ImportBlacklistPatterns records the patterns as blacklisted in memory if the
+// blacklist has never been changed, and returns nil.
+func (m *MockBpdb) ImportBlacklistPatterns(patterns []string, user, reason string) error {
+	m.blacklistMutex.Lock()
+	defer m.blacklistMutex.Unlock()
+	if len(m.blacklist) > 0 {
+		return nil
+	}
+	for _, pattern := range patterns {
+		m.blacklist = append(m.blacklist, bpdb.BlacklistChange{
+			Version:     len(m.blacklist) + 1,
+			Pattern:     pattern,
+			Blacklisted: true,
+			User:        user,
+			Reason:      reason,
+		})
+	}
+	return nil
+}